@@ -1,5 +1,9 @@
 package weixin
 
+import (
+	"context"
+)
+
 type accounts interface {
 	GetAccountById(string) (*Weixin, error)
 }
@@ -96,10 +100,10 @@ func (u *Accounts) GetSubscribeUrl(appId string) (string, error) {
 	return account.SubscribeUrl, nil
 }
 
-func (u *Accounts) SendTemplate(appId string, template *TemplateReq) error {
+func (u *Accounts) SendTemplate(appId string, template *TemplateReq) (int64, error) {
 	account, err := u.Accounts.GetAccountById(appId)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	return account.SendTemplate(template)
 }
@@ -111,3 +115,331 @@ func (u *Accounts) GetUserPhoneNumber(appId string, code string) (*UserPhoneNumb
 	}
 	return account.GetUserPhoneNumber(code)
 }
+
+func (u *Accounts) HandleMessage(appId string, msg *Message) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.HandleMessage(msg)
+}
+
+func (u *Accounts) MassSendAll(appId string, params *MassSendAllReq) (*MassJob, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.MassSendAll(params)
+}
+
+func (u *Accounts) MassSend(appId string, params *MassSendReq) (*MassJob, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.MassSend(params)
+}
+
+func (u *Accounts) MassPreview(appId string, params *MassPreviewReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.MassPreview(params)
+}
+
+func (u *Accounts) MassDelete(appId string, params *MassDeleteReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.MassDelete(params)
+}
+
+func (u *Accounts) MassGet(appId string, msgId int64) (*MassGetRes, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.MassGet(msgId)
+}
+
+func (u *Accounts) GetMassJob(appId string, jobId string) (*MassJob, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetMassJob(jobId)
+}
+
+func (u *Accounts) GetAllPrivateTemplate(appId string) ([]*PrivateTemplate, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetAllPrivateTemplate()
+}
+
+func (u *Accounts) ValidateTemplate(appId string, template *TemplateReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.ValidateTemplate(template)
+}
+
+func (u *Accounts) GetTemplateSend(appId string, msgId int64) (*TemplateSendRecord, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetTemplateSend(msgId)
+}
+
+func (u *Accounts) SendTemplateBatch(ctx context.Context, appId string, params *TemplateBatchReq) (*TemplateBatchReport, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.SendTemplateBatch(ctx, params)
+}
+
+func (u *Accounts) BizSend(appId string, params *BizSendReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.BizSend(params)
+}
+
+func (u *Accounts) GetSubscribeTemplate(appId string) ([]*SubscribeTemplate, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetSubscribeTemplate()
+}
+
+func (u *Accounts) GetSubscribeMsgUrl(appId string, params *SubscribeMsgUrlReq) (string, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return "", err
+	}
+	return account.GetSubscribeMsgUrl(params)
+}
+
+func (u *Accounts) SendSubscribeMsg(appId string, params *SubscribeMsgReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.SendSubscribeMsg(params)
+}
+
+func (u *Accounts) SendMiniProgramSubscribe(appId string, params *MiniProgramSubscribeReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.SendMiniProgramSubscribe(params)
+}
+
+func (u *Accounts) Code2Session(appId string, code string) (*Session, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.Code2Session(code)
+}
+
+func (u *Accounts) CheckSession(appId string, openId string) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.CheckSession(openId)
+}
+
+func (u *Accounts) ResetUserSessionKey(appId string, openId string) (*Session, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.ResetUserSessionKey(openId)
+}
+
+func (u *Accounts) DecryptUserInfo(appId string, params *EncryptedDataReq) (*DecryptedUserInfo, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.DecryptUserInfo(params)
+}
+
+func (u *Accounts) DecryptWeRunData(appId string, params *EncryptedDataReq) (*WeRunData, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.DecryptWeRunData(params)
+}
+
+func (u *Accounts) DecryptShareInfo(appId string, params *EncryptedDataReq) (*ShareInfo, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.DecryptShareInfo(params)
+}
+
+func (u *Accounts) DecryptPhoneNumber(appId string, params *EncryptedDataReq) (*DecryptedPhoneNumber, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.DecryptPhoneNumber(params)
+}
+
+func (u *Accounts) BindUserPhoneNumber(appId string, openId string, code string) (*UserPhoneNumber, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.BindUserPhoneNumber(openId, code)
+}
+
+func (u *Accounts) GetPhoneBinding(appId string, openId string) (*PhoneBinding, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetPhoneBinding(openId)
+}
+
+func (u *Accounts) GetWxaCode(appId string, params *WxaCodeReq) ([]byte, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetWxaCode(params)
+}
+
+func (u *Accounts) GetWxaCodeUnlimit(appId string, params *WxaCodeUnlimitReq) ([]byte, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetWxaCodeUnlimit(params)
+}
+
+func (u *Accounts) CreateWxaQrCode(appId string, params *WxaQrCodeReq) ([]byte, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.CreateWxaQrCode(params)
+}
+
+func (u *Accounts) GenerateScheme(appId string, params *GenerateSchemeReq) (string, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return "", err
+	}
+	return account.GenerateScheme(params)
+}
+
+func (u *Accounts) GenerateUrlLink(appId string, params *GenerateUrlLinkReq) (string, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return "", err
+	}
+	return account.GenerateUrlLink(params)
+}
+
+func (u *Accounts) GenerateShortLink(appId string, params *GenerateShortLinkReq) (string, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return "", err
+	}
+	return account.GenerateShortLink(params)
+}
+
+func (u *Accounts) QueryScheme(appId string, scheme string) (*QuerySchemeRes, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.QueryScheme(scheme)
+}
+
+func (u *Accounts) QueryUrlLink(appId string, urlLink string) (*QueryUrlLinkRes, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.QueryUrlLink(urlLink)
+}
+
+func (u *Accounts) CreateQrCode(appId string, params *QrCodeReq) (*QrCodeRes, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.CreateQrCode(params)
+}
+
+func (u *Accounts) GetQrCodeScene(appId string, openId string) (string, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return "", err
+	}
+	return account.GetQrCodeScene(openId)
+}
+
+func (u *Accounts) MsgSecCheck(appId string, params *MsgSecCheckReq) (*MsgSecCheckRes, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.MsgSecCheck(params)
+}
+
+func (u *Accounts) MediaCheckAsync(appId string, params *MediaCheckAsyncReq) (string, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return "", err
+	}
+	return account.MediaCheckAsync(params)
+}
+
+func (u *Accounts) UploadShippingInfo(appId string, params *UploadShippingInfoReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.UploadShippingInfo(params)
+}
+
+func (u *Accounts) UploadCombinedShippingInfo(appId string, params *UploadCombinedShippingInfoReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.UploadCombinedShippingInfo(params)
+}
+
+func (u *Accounts) GetShippingOrder(appId string, params *GetShippingOrderReq) (*ShippingOrder, error) {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetShippingOrder(params)
+}
+
+func (u *Accounts) NotifyConfirmReceive(appId string, params *NotifyConfirmReceiveReq) error {
+	account, err := u.Accounts.GetAccountById(appId)
+	if err != nil {
+		return err
+	}
+	return account.NotifyConfirmReceive(params)
+}
@@ -0,0 +1,12 @@
+package base
+
+type TokenSource interface {
+	GetAccessToken(appId string) (string, int64, error)
+	ResetAccessToken(appId string) error
+}
+
+type ComponentTokenSource interface {
+	TokenSource
+	ComponentAppId() string
+	ComponentAccessToken() (string, error)
+}
@@ -30,7 +30,7 @@ func New(c *Config) *Weixin {
 	if c.AppId == "" {
 		panic("AppId 必须设置")
 	}
-	if c.Secret == "" {
+	if c.Secret == "" && c.TokenSource == nil {
 		panic("Secret 必须设置")
 	}
 	if c.Logger == nil {
@@ -46,10 +46,11 @@ func New(c *Config) *Weixin {
 }
 
 type Config struct {
-	AppId  string        `json:"appId"`
-	Secret string        `json:"secret"`
-	Logger logger.Logger `json:"logger"`
-	Redis  *redis.Redis  `json:"redis"`
+	AppId       string        `json:"appId"`
+	Secret      string        `json:"secret"`
+	Logger      logger.Logger `json:"logger"`
+	Redis       *redis.Redis  `json:"redis"`
+	TokenSource TokenSource   `json:"-"`
 }
 
 type AccessToken struct {
@@ -95,6 +96,15 @@ func (wx *Weixin) ClearAccessToken() {
 	}
 }
 
+func (wx *Weixin) ResetAccessToken() error {
+	wx.ClearAccessToken()
+	if wx.TokenSource != nil {
+		return wx.TokenSource.ResetAccessToken(wx.AppId)
+	}
+	_, err := wx.Redis.Del(context.Background(), AccessTokenPrefix+wx.AppId).Result()
+	return err
+}
+
 func (wx *Weixin) SetAccessToken(accessToken string, expiresIn int64) {
 
 	wx.accessToken = &AccessToken{
@@ -174,6 +184,16 @@ func (wx *Weixin) GetAccessToken() (a *AccessToken, err error) {
 		return wx.accessToken, nil
 	}
 
+	if wx.TokenSource != nil {
+		accessToken, expiresIn, err := wx.TokenSource.GetAccessToken(wx.AppId)
+		if err != nil {
+			return nil, err
+		}
+		wx.SetAccessToken(accessToken, expiresIn)
+		wx.Logger.Debug("GetAccessToken from token source", wx.Logger.Field("appId", wx.AppId))
+		return wx.accessToken, nil
+	}
+
 	accessToken, err := wx.Redis.Get(context.Background(), AccessTokenPrefix+wx.AppId).Result()
 	ttl, err := wx.Redis.TTL(context.Background(), AccessTokenPrefix+wx.AppId).Result()
 	if accessToken != "" && ttl > 0 {
@@ -274,9 +294,7 @@ func (wx *Weixin) GetJsApiTicket() (j *JsApiTicket, err error) {
 
 	if res.Ticket == "" || res.ExpiresIn == 0 {
 		if res.ErrCode == 40001 || res.ErrCode == 42001 {
-			wx.ClearAccessToken()
-			_, err := wx.Redis.Del(context.Background(), AccessTokenPrefix+wx.AppId).Result()
-			if err != nil {
+			if err := wx.ResetAccessToken(); err != nil {
 				return nil, err
 			}
 			return wx.GetJsApiTicket()
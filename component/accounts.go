@@ -0,0 +1,173 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"github.com/go-tron/weixin"
+	"github.com/go-tron/weixin/base"
+	"sync"
+)
+
+type TokenSource struct {
+	Component *Component
+}
+
+func (s *TokenSource) GetAccessToken(appId string) (string, int64, error) {
+	return s.Component.GetAuthorizerAccessToken(appId)
+}
+
+func (s *TokenSource) ResetAccessToken(appId string) error {
+	return s.Component.ResetAuthorizerAccessToken(appId)
+}
+
+func (s *TokenSource) ComponentAppId() string {
+	return s.Component.AppId
+}
+
+func (s *TokenSource) ComponentAccessToken() (string, error) {
+	accessToken, err := s.Component.GetAccessToken()
+	if err != nil {
+		return "", err
+	}
+	return accessToken.AccessToken, nil
+}
+
+func (c *Component) TokenSource() base.TokenSource {
+	return &TokenSource{Component: c}
+}
+
+func (c *Component) Authorized(authorizerAppId string) (bool, error) {
+	n, err := c.Redis.Exists(context.Background(), AuthorizerRefreshTokenPrefix+c.AppId+":"+authorizerAppId).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (c *Component) NewWeixin(authorizerAppId string) (*weixin.Weixin, error) {
+	authorized, err := c.Authorized(authorizerAppId)
+	if err != nil {
+		return nil, err
+	}
+	if !authorized {
+		return nil, errors.New("authorizer 未授权:" + authorizerAppId)
+	}
+	return weixin.New(&weixin.Config{
+		Name:        authorizerAppId,
+		AppId:       authorizerAppId,
+		Token:       c.Token,
+		Logger:      c.Logger,
+		Redis:       c.Redis,
+		TokenSource: c.TokenSource(),
+	}), nil
+}
+
+func (c *Component) NewBase(authorizerAppId string) (*base.Weixin, error) {
+	authorized, err := c.Authorized(authorizerAppId)
+	if err != nil {
+		return nil, err
+	}
+	if !authorized {
+		return nil, errors.New("authorizer 未授权:" + authorizerAppId)
+	}
+	return base.New(&base.Config{
+		AppId:       authorizerAppId,
+		Logger:      c.Logger,
+		Redis:       c.Redis,
+		TokenSource: c.TokenSource(),
+	}), nil
+}
+
+func NewAccounts(c *Component) *Accounts {
+	a := &Accounts{
+		Component: c,
+		accounts:  make(map[string]*weixin.Weixin),
+	}
+	c.OnUnauthorized(a.Remove)
+	return a
+}
+
+type Accounts struct {
+	Component *Component
+	lock      sync.Mutex
+	accounts  map[string]*weixin.Weixin
+}
+
+func (a *Accounts) GetAccountById(appId string) (*weixin.Weixin, error) {
+	a.lock.Lock()
+	account, ok := a.accounts[appId]
+	a.lock.Unlock()
+	if ok {
+		return account, nil
+	}
+
+	account, err := a.Component.NewWeixin(appId)
+	if err != nil {
+		return nil, err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if cached, ok := a.accounts[appId]; ok {
+		return cached, nil
+	}
+	a.accounts[appId] = account
+	return account, nil
+}
+
+func (a *Accounts) Remove(appId string) {
+	a.lock.Lock()
+	account, ok := a.accounts[appId]
+	delete(a.accounts, appId)
+	a.lock.Unlock()
+	if ok {
+		account.ClearAccessToken()
+	}
+}
+
+func NewBaseAccounts(c *Component) *BaseAccounts {
+	a := &BaseAccounts{
+		Component: c,
+		accounts:  make(map[string]*base.Weixin),
+	}
+	c.OnUnauthorized(a.Remove)
+	return a
+}
+
+type BaseAccounts struct {
+	Component *Component
+	lock      sync.Mutex
+	accounts  map[string]*base.Weixin
+}
+
+func (a *BaseAccounts) GetAccountById(appId string) (*base.Weixin, error) {
+	a.lock.Lock()
+	account, ok := a.accounts[appId]
+	a.lock.Unlock()
+	if ok {
+		return account, nil
+	}
+
+	account, err := a.Component.NewBase(appId)
+	if err != nil {
+		return nil, err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if cached, ok := a.accounts[appId]; ok {
+		return cached, nil
+	}
+	a.accounts[appId] = account
+	return account, nil
+}
+
+func (a *BaseAccounts) Remove(appId string) {
+	a.lock.Lock()
+	account, ok := a.accounts[appId]
+	delete(a.accounts, appId)
+	a.lock.Unlock()
+	if ok {
+		account.ClearAccessToken()
+	}
+}
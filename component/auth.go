@@ -0,0 +1,280 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"github.com/go-tron/random"
+	"github.com/go-tron/redis"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	AuthorizerAccessTokenPrefix  = "wx-authorizer-access-token:"
+	AuthorizerRefreshTokenPrefix = "wx-authorizer-refresh-token:"
+	AuthorizerRefreshLockPrefix  = "wx-authorizer-refresh-lock:"
+	AuthorizerRefreshLockExpire  = time.Second * 10
+	AuthorizerRefreshLockWait    = time.Millisecond * 100
+)
+
+var delIfEqual = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+type AuthType int
+
+const (
+	AuthTypeOfficialAccount AuthType = 1
+	AuthTypeMiniProgram     AuthType = 2
+	AuthTypeAll             AuthType = 3
+)
+
+type PreAuthCodeRes struct {
+	PreAuthCode string `json:"pre_auth_code"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func (c *Component) CreatePreAuthCode() (string, error) {
+	var res = &PreAuthCodeRes{}
+	if err := c.post("CreatePreAuthCode", c.BaseUrl+"/cgi-bin/component/api_create_preauthcode", map[string]string{
+		"component_appid": c.AppId,
+	}, res); err != nil {
+		return "", err
+	}
+	return res.PreAuthCode, nil
+}
+
+type AuthUrlReq struct {
+	RedirectUri string   `json:"redirectUri"`
+	AuthType    AuthType `json:"authType"`
+	BizAppId    string   `json:"bizAppId"`
+}
+
+func (c *Component) authUrlValues(params *AuthUrlReq) (url.Values, error) {
+	if params.RedirectUri == "" {
+		return nil, errors.New("redirectUri 必须设置")
+	}
+	preAuthCode, err := c.CreatePreAuthCode()
+	if err != nil {
+		return nil, err
+	}
+	v := url.Values{}
+	v.Set("component_appid", c.AppId)
+	v.Set("pre_auth_code", preAuthCode)
+	v.Set("redirect_uri", params.RedirectUri)
+	if params.AuthType != 0 {
+		v.Set("auth_type", strconv.Itoa(int(params.AuthType)))
+	}
+	if params.BizAppId != "" {
+		v.Set("biz_appid", params.BizAppId)
+	}
+	return v, nil
+}
+
+func (c *Component) GetAuthUrl(params *AuthUrlReq) (string, error) {
+	v, err := c.authUrlValues(params)
+	if err != nil {
+		return "", err
+	}
+	return "https://mp.weixin.qq.com/cgi-bin/componentloginpage?" + v.Encode(), nil
+}
+
+func (c *Component) GetMobileAuthUrl(params *AuthUrlReq) (string, error) {
+	v, err := c.authUrlValues(params)
+	if err != nil {
+		return "", err
+	}
+	v.Set("action", "bindcomponent")
+	v.Set("no_scan", "1")
+	return "https://open.weixin.qq.com/wxaopen/safe/bindcomponent?" + v.Encode() + "#wechat_redirect", nil
+}
+
+type FuncScope struct {
+	Id int `json:"id"`
+}
+
+type FuncInfo struct {
+	FuncScopeCategory FuncScope `json:"funcscope_category"`
+}
+
+type AuthorizationInfo struct {
+	AuthorizerAppId        string      `json:"authorizer_appid"`
+	AuthorizerAccessToken  string      `json:"authorizer_access_token"`
+	ExpiresIn              int64       `json:"expires_in"`
+	AuthorizerRefreshToken string      `json:"authorizer_refresh_token"`
+	FuncInfo               []*FuncInfo `json:"func_info"`
+}
+
+type QueryAuthRes struct {
+	AuthorizationInfo *AuthorizationInfo `json:"authorization_info"`
+}
+
+func (c *Component) saveAuthorizerToken(authorizerAppId string, accessToken string, expiresIn int64, refreshToken string) error {
+	pipe := c.Redis.TxPipeline()
+	pipe.Set(context.Background(), AuthorizerAccessTokenPrefix+c.AppId+":"+authorizerAppId, accessToken, time.Second*time.Duration(expiresIn))
+	if refreshToken != "" {
+		pipe.Set(context.Background(), AuthorizerRefreshTokenPrefix+c.AppId+":"+authorizerAppId, refreshToken, 0)
+	}
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+func (c *Component) QueryAuth(authorizationCode string) (*AuthorizationInfo, error) {
+	var res = &QueryAuthRes{}
+	if err := c.post("QueryAuth", c.BaseUrl+"/cgi-bin/component/api_query_auth", map[string]string{
+		"component_appid":    c.AppId,
+		"authorization_code": authorizationCode,
+	}, res); err != nil {
+		return nil, err
+	}
+	info := res.AuthorizationInfo
+	if info == nil || info.AuthorizerAppId == "" {
+		return nil, errors.New("authorization_info 不存在")
+	}
+	if err := c.saveAuthorizerToken(info.AuthorizerAppId, info.AuthorizerAccessToken, info.ExpiresIn, info.AuthorizerRefreshToken); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+type AuthorizerTokenRes struct {
+	AuthorizerAccessToken  string `json:"authorizer_access_token"`
+	ExpiresIn              int64  `json:"expires_in"`
+	AuthorizerRefreshToken string `json:"authorizer_refresh_token"`
+}
+
+func (c *Component) RefreshAuthorizerToken(authorizerAppId string) (*AuthorizerTokenRes, error) {
+	refreshToken, err := c.Redis.Get(context.Background(), AuthorizerRefreshTokenPrefix+c.AppId+":"+authorizerAppId).Result()
+	if err == redis.Nil {
+		return nil, errors.New("authorizer_refresh_token 不存在:" + authorizerAppId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res = &AuthorizerTokenRes{}
+	if err := c.post("RefreshAuthorizerToken", c.BaseUrl+"/cgi-bin/component/api_authorizer_token", map[string]string{
+		"component_appid":          c.AppId,
+		"authorizer_appid":         authorizerAppId,
+		"authorizer_refresh_token": refreshToken,
+	}, res); err != nil {
+		return nil, err
+	}
+	if err := c.saveAuthorizerToken(authorizerAppId, res.AuthorizerAccessToken, res.ExpiresIn, res.AuthorizerRefreshToken); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Component) cachedAuthorizerAccessToken(authorizerAppId string) (string, int64, error) {
+	key := AuthorizerAccessTokenPrefix + c.AppId + ":" + authorizerAppId
+	accessToken, err := c.Redis.Get(context.Background(), key).Result()
+	if err == redis.Nil {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	ttl, err := c.Redis.TTL(context.Background(), key).Result()
+	if err != nil {
+		return "", 0, err
+	}
+	if ttl <= 0 {
+		return "", 0, nil
+	}
+	return accessToken, int64(ttl / time.Second), nil
+}
+
+func (c *Component) authorizerLock(authorizerAppId string) *sync.Mutex {
+	lock, _ := c.authorizerLocks.LoadOrStore(authorizerAppId, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// every api_authorizer_token call invalidates the previous token, so only one
+// refresh per authorizer may run at a time across processes
+func (c *Component) GetAuthorizerAccessToken(authorizerAppId string) (string, int64, error) {
+	if accessToken, expiresIn, err := c.cachedAuthorizerAccessToken(authorizerAppId); err != nil || accessToken != "" {
+		return accessToken, expiresIn, err
+	}
+
+	lock := c.authorizerLock(authorizerAppId)
+	lock.Lock()
+	defer lock.Unlock()
+
+	lockKey := AuthorizerRefreshLockPrefix + c.AppId + ":" + authorizerAppId
+	lockValue := random.String(16)
+	deadline := time.Now().Add(AuthorizerRefreshLockExpire)
+	for {
+		accessToken, expiresIn, err := c.cachedAuthorizerAccessToken(authorizerAppId)
+		if err != nil || accessToken != "" {
+			return accessToken, expiresIn, err
+		}
+		locked, err := c.Redis.SetNX(context.Background(), lockKey, lockValue, AuthorizerRefreshLockExpire).Result()
+		if err != nil {
+			return "", 0, err
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			return "", 0, errors.New("authorizer_access_token 刷新超时:" + authorizerAppId)
+		}
+		time.Sleep(AuthorizerRefreshLockWait)
+	}
+	defer delIfEqual.Run(context.Background(), c.Redis, []string{lockKey}, lockValue)
+
+	res, err := c.RefreshAuthorizerToken(authorizerAppId)
+	if err != nil {
+		return "", 0, err
+	}
+	return res.AuthorizerAccessToken, res.ExpiresIn, nil
+}
+
+func (c *Component) ResetAuthorizerAccessToken(authorizerAppId string) error {
+	_, err := c.Redis.Del(context.Background(), AuthorizerAccessTokenPrefix+c.AppId+":"+authorizerAppId).Result()
+	return err
+}
+
+// drops the cached token only if it is still the one that was rejected, so a
+// late 40001 does not throw away a token another caller just refreshed
+func (c *Component) resetAuthorizerAccessToken(authorizerAppId string, accessToken string) error {
+	return delIfEqual.Run(context.Background(), c.Redis, []string{AuthorizerAccessTokenPrefix + c.AppId + ":" + authorizerAppId}, accessToken).Err()
+}
+
+func (c *Component) ClearAuthorizer(authorizerAppId string) error {
+	_, err := c.Redis.Del(context.Background(),
+		AuthorizerAccessTokenPrefix+c.AppId+":"+authorizerAppId,
+		AuthorizerRefreshTokenPrefix+c.AppId+":"+authorizerAppId).Result()
+	return err
+}
+
+type AuthorizerInfo struct {
+	NickName        string `json:"nick_name"`
+	HeadImg         string `json:"head_img"`
+	UserName        string `json:"user_name"`
+	PrincipalName   string `json:"principal_name"`
+	Alias           string `json:"alias"`
+	QrCodeUrl       string `json:"qrcode_url"`
+	ServiceTypeInfo struct {
+		Id int `json:"id"`
+	} `json:"service_type_info"`
+	VerifyTypeInfo struct {
+		Id int `json:"id"`
+	} `json:"verify_type_info"`
+}
+
+type GetAuthorizerInfoRes struct {
+	AuthorizerInfo    *AuthorizerInfo    `json:"authorizer_info"`
+	AuthorizationInfo *AuthorizationInfo `json:"authorization_info"`
+}
+
+func (c *Component) GetAuthorizerInfo(authorizerAppId string) (*GetAuthorizerInfoRes, error) {
+	var res = &GetAuthorizerInfoRes{}
+	if err := c.post("GetAuthorizerInfo", c.BaseUrl+"/cgi-bin/component/api_get_authorizer_info", map[string]string{
+		"component_appid":  c.AppId,
+		"authorizer_appid": authorizerAppId,
+	}, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
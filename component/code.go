@@ -0,0 +1,166 @@
+package component
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+type CommitCodeReq struct {
+	TemplateId  string      `json:"template_id"`
+	ExtJson     interface{} `json:"ext_json"`
+	UserVersion string      `json:"user_version"`
+	UserDesc    string      `json:"user_desc"`
+}
+
+func (c *Component) CommitCode(authorizerAppId string, params *CommitCodeReq) error {
+	extJson := "{}"
+	switch v := params.ExtJson.(type) {
+	case nil:
+	case string:
+		extJson = v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		extJson = string(data)
+	}
+	return c.authorizerPost("CommitCode", authorizerAppId, c.BaseUrl+"/wxa/commit", map[string]string{
+		"template_id":  params.TemplateId,
+		"ext_json":     extJson,
+		"user_version": params.UserVersion,
+		"user_desc":    params.UserDesc,
+	}, nil)
+}
+
+type GetCodePageRes struct {
+	PageList []string `json:"page_list"`
+}
+
+func (c *Component) GetCodePage(authorizerAppId string) ([]string, error) {
+	var res = &GetCodePageRes{}
+	if err := c.authorizerGet("GetCodePage", authorizerAppId, c.BaseUrl+"/wxa/get_page", nil, res); err != nil {
+		return nil, err
+	}
+	return res.PageList, nil
+}
+
+func (c *Component) GetTrialQrCode(authorizerAppId string, path string) ([]byte, error) {
+	var params map[string]string
+	if path != "" {
+		params = map[string]string{
+			"path": path,
+		}
+	}
+	resp, err := c.authorizerRequest("GetTrialQrCode", authorizerAppId, "GET", c.BaseUrl+"/wxa/get_qrcode", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(resp.Header().Get("Content-Type"), "image/") {
+		return resp.Body(), nil
+	}
+	if err := decodeRes("GetTrialQrCode", resp.Body(), nil); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("qrcode 不存在")
+}
+
+type AuditItem struct {
+	Address     string `json:"address,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	FirstClass  string `json:"first_class,omitempty"`
+	SecondClass string `json:"second_class,omitempty"`
+	ThirdClass  string `json:"third_class,omitempty"`
+	FirstId     int    `json:"first_id,omitempty"`
+	SecondId    int    `json:"second_id,omitempty"`
+	ThirdId     int    `json:"third_id,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+type SubmitAuditReq struct {
+	ItemList         []*AuditItem `json:"item_list,omitempty"`
+	FeedbackInfo     string       `json:"feedback_info,omitempty"`
+	FeedbackStuff    string       `json:"feedback_stuff,omitempty"`
+	VersionDesc      string       `json:"version_desc,omitempty"`
+	PrivacyApiNotUse bool         `json:"privacy_api_not_use,omitempty"`
+}
+
+type SubmitAuditRes struct {
+	AuditId int64 `json:"auditid"`
+}
+
+func (c *Component) SubmitAudit(authorizerAppId string, params *SubmitAuditReq) (int64, error) {
+	if params == nil {
+		params = &SubmitAuditReq{}
+	}
+	var res = &SubmitAuditRes{}
+	if err := c.authorizerPost("SubmitAudit", authorizerAppId, c.BaseUrl+"/wxa/submit_audit", params, res); err != nil {
+		return 0, err
+	}
+	return res.AuditId, nil
+}
+
+type AuditStatus int
+
+const (
+	AuditStatusSuccess   AuditStatus = 0
+	AuditStatusRejected  AuditStatus = 1
+	AuditStatusAuditing  AuditStatus = 2
+	AuditStatusWithdrawn AuditStatus = 3
+	AuditStatusDelaying  AuditStatus = 4
+)
+
+type AuditStatusRes struct {
+	AuditId         int64       `json:"auditid"`
+	Status          AuditStatus `json:"status"`
+	Reason          string      `json:"reason"`
+	ScreenShot      string      `json:"screenshot"`
+	UserVersion     string      `json:"user_version"`
+	UserDesc        string      `json:"user_desc"`
+	SubmitAuditTime int64       `json:"submit_audit_time"`
+}
+
+func (c *Component) GetAuditStatus(authorizerAppId string, auditId int64) (*AuditStatusRes, error) {
+	var res = &AuditStatusRes{}
+	if err := c.authorizerPost("GetAuditStatus", authorizerAppId, c.BaseUrl+"/wxa/get_auditstatus", map[string]int64{
+		"auditid": auditId,
+	}, res); err != nil {
+		return nil, err
+	}
+	res.AuditId = auditId
+	return res, nil
+}
+
+func (c *Component) GetLatestAuditStatus(authorizerAppId string) (*AuditStatusRes, error) {
+	var res = &AuditStatusRes{}
+	if err := c.authorizerGet("GetLatestAuditStatus", authorizerAppId, c.BaseUrl+"/wxa/get_latest_auditstatus", nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Component) UndoCodeAudit(authorizerAppId string) error {
+	return c.authorizerGet("UndoCodeAudit", authorizerAppId, c.BaseUrl+"/wxa/undocodeaudit", nil, nil)
+}
+
+func (c *Component) Release(authorizerAppId string) error {
+	return c.authorizerPost("Release", authorizerAppId, c.BaseUrl+"/wxa/release", map[string]string{}, nil)
+}
+
+func (c *Component) RevertCodeRelease(authorizerAppId string) error {
+	return c.authorizerGet("RevertCodeRelease", authorizerAppId, c.BaseUrl+"/wxa/revertcoderelease", nil, nil)
+}
+
+func (c *Component) GrayRelease(authorizerAppId string, grayPercentage int) error {
+	if grayPercentage < 1 || grayPercentage > 100 {
+		return errors.New("grayPercentage 取值范围1-100")
+	}
+	return c.authorizerPost("GrayRelease", authorizerAppId, c.BaseUrl+"/wxa/grayrelease", map[string]int{
+		"gray_percentage": grayPercentage,
+	}, nil)
+}
+
+func (c *Component) RevertGrayRelease(authorizerAppId string) error {
+	return c.authorizerGet("RevertGrayRelease", authorizerAppId, c.BaseUrl+"/wxa/revertgrayrelease", nil, nil)
+}
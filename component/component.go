@@ -0,0 +1,334 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/go-tron/config"
+	"github.com/go-tron/logger"
+	"github.com/go-tron/redis"
+	"sync"
+	"time"
+)
+
+const BaseUrl = "https://api.weixin.qq.com"
+
+func NewWithConfig(c *config.Config, redis *redis.Redis) *Component {
+	return New(&Config{
+		AppId:          c.GetString("weixin.component.appId"),
+		AppSecret:      c.GetString("weixin.component.appSecret"),
+		Token:          c.GetString("weixin.component.token"),
+		EncodingAESKey: c.GetString("weixin.component.encodingAESKey"),
+		Redis:          redis,
+		Logger:         logger.NewZapWithConfig(c, "weixin-component", "info"),
+	})
+}
+
+func New(c *Config) *Component {
+
+	if c == nil {
+		panic("config 必须设置")
+	}
+	if c.AppId == "" {
+		panic("AppId 必须设置")
+	}
+	if c.AppSecret == "" {
+		panic("AppSecret 必须设置")
+	}
+	if c.Token == "" {
+		panic("Token 必须设置")
+	}
+	if c.EncodingAESKey == "" {
+		panic("EncodingAESKey 必须设置")
+	}
+	if c.Logger == nil {
+		panic("Logger 必须设置")
+	}
+	if c.Redis == nil {
+		panic("Redis 必须设置")
+	}
+	if c.BaseUrl == "" {
+		c.BaseUrl = BaseUrl
+	}
+
+	return &Component{
+		Config: c,
+	}
+}
+
+type Config struct {
+	AppId          string        `json:"appId"`
+	AppSecret      string        `json:"appSecret"`
+	Token          string        `json:"token"`
+	EncodingAESKey string        `json:"encodingAESKey"`
+	Logger         logger.Logger `json:"logger"`
+	Redis          *redis.Redis  `json:"redis"`
+	BaseUrl        string        `json:"baseUrl"`
+}
+
+type AccessToken struct {
+	AccessToken string `json:"component_access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type Component struct {
+	*Config
+	accessTokenLock   sync.Mutex
+	accessToken       string
+	accessTokenExpire time.Time
+	authorizerLocks   sync.Map
+	unauthorizedLock  sync.Mutex
+	unauthorizedHooks []func(authorizerAppId string)
+}
+
+const (
+	VerifyTicketPrefix = "wx-component-verify-ticket:"
+	VerifyTicketExpire = time.Hour * 12
+	AccessTokenPrefix  = "wx-component-access-token:"
+)
+
+type Error struct {
+	Code int    `json:"errcode"`
+	Msg  string `json:"errmsg"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("(%d)%s", e.Code, e.Msg)
+}
+
+func decodeRes(name string, body []byte, res interface{}) error {
+	var e = &Error{}
+	if err := json.Unmarshal(body, e); err != nil {
+		return err
+	}
+	if e.Code != 0 {
+		if e.Msg == "" {
+			e.Msg = name
+		}
+		return e
+	}
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(body, res)
+}
+
+func (c *Component) SetVerifyTicket(ticket string) error {
+	_, err := c.Redis.Set(context.Background(), VerifyTicketPrefix+c.AppId, ticket, VerifyTicketExpire).Result()
+	return err
+}
+
+func (c *Component) GetVerifyTicket() (string, error) {
+	ticket, err := c.Redis.Get(context.Background(), VerifyTicketPrefix+c.AppId).Result()
+	if err == redis.Nil {
+		return "", errors.New("component_verify_ticket 不存在")
+	}
+	return ticket, err
+}
+
+func (c *Component) ClearAccessToken() {
+	c.accessTokenLock.Lock()
+	defer c.accessTokenLock.Unlock()
+	c.clearAccessToken()
+}
+
+func (c *Component) clearAccessToken() {
+	c.accessToken = ""
+	c.accessTokenExpire = time.Time{}
+}
+
+func (c *Component) SetAccessToken(accessToken string, expiresIn int64) {
+	c.accessTokenLock.Lock()
+	defer c.accessTokenLock.Unlock()
+	c.setAccessToken(accessToken, expiresIn)
+}
+
+func (c *Component) setAccessToken(accessToken string, expiresIn int64) {
+	c.accessToken = accessToken
+	c.accessTokenExpire = time.Now().Add(time.Second * time.Duration(expiresIn))
+}
+
+func (c *Component) cachedAccessToken() *AccessToken {
+	return &AccessToken{
+		AccessToken: c.accessToken,
+		ExpiresIn:   int64(time.Until(c.accessTokenExpire) / time.Second),
+	}
+}
+
+func (c *Component) GetAccessToken() (a *AccessToken, err error) {
+
+	c.accessTokenLock.Lock()
+	defer func() {
+		if err != nil {
+			c.Logger.Error("GetComponentAccessToken", c.Logger.Field("error", err), c.Logger.Field("componentAppId", c.AppId))
+		}
+		c.accessTokenLock.Unlock()
+	}()
+
+	if c.accessToken != "" && time.Now().Before(c.accessTokenExpire) {
+		c.Logger.Debug("GetComponentAccessToken from application", c.Logger.Field("componentAppId", c.AppId))
+		return c.cachedAccessToken(), nil
+	}
+
+	accessToken, err := c.Redis.Get(context.Background(), AccessTokenPrefix+c.AppId).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	ttl, err := c.Redis.TTL(context.Background(), AccessTokenPrefix+c.AppId).Result()
+	if err != nil {
+		return nil, err
+	}
+	if accessToken != "" && ttl > 0 {
+		c.setAccessToken(accessToken, int64(ttl/time.Second))
+		c.Logger.Debug("GetComponentAccessToken from redis", c.Logger.Field("componentAppId", c.AppId))
+		return c.cachedAccessToken(), nil
+	}
+
+	ticket, err := c.GetVerifyTicket()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := resty.New().R().
+		SetBody(map[string]string{
+			"component_appid":         c.AppId,
+			"component_appsecret":     c.AppSecret,
+			"component_verify_ticket": ticket,
+		}).
+		Post(c.BaseUrl + "/cgi-bin/component/api_component_token")
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Debug("GetComponentAccessToken", c.Logger.Field("response", resp.Body()), c.Logger.Field("componentAppId", c.AppId))
+
+	var res = &AccessToken{}
+	if err := decodeRes("GetComponentAccessToken", resp.Body(), res); err != nil {
+		return nil, err
+	}
+	if res.AccessToken == "" || res.ExpiresIn == 0 {
+		return nil, errors.New("request failed")
+	}
+
+	c.setAccessToken(res.AccessToken, res.ExpiresIn)
+
+	c.Redis.Set(context.Background(), AccessTokenPrefix+c.AppId, res.AccessToken, time.Second*time.Duration(res.ExpiresIn)).Result()
+
+	c.Logger.Debug("GetComponentAccessToken from request", c.Logger.Field("componentAppId", c.AppId))
+	return c.cachedAccessToken(), nil
+}
+
+func isTokenExpired(body []byte) bool {
+	var e = &Error{}
+	return json.Unmarshal(body, e) == nil && (e.Code == 40001 || e.Code == 42001)
+}
+
+// drops the component token only if it is still the rejected one
+func (c *Component) resetAccessToken(accessToken string) error {
+	c.accessTokenLock.Lock()
+	if c.accessToken == accessToken {
+		c.clearAccessToken()
+	}
+	c.accessTokenLock.Unlock()
+	return delIfEqual.Run(context.Background(), c.Redis, []string{AccessTokenPrefix + c.AppId}, accessToken).Err()
+}
+
+func (c *Component) request(name string, method string, url string, tokenParam string, params map[string]string, body interface{}) (*resty.Response, error) {
+	for attempt := 0; ; attempt++ {
+		accessToken, err := c.GetAccessToken()
+		if err != nil {
+			return nil, err
+		}
+
+		req := resty.New().R().
+			SetQueryParams(params).
+			SetQueryParam(tokenParam, accessToken.AccessToken)
+		if body != nil {
+			req.SetBody(body)
+		}
+		resp, err := req.Execute(method, url)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Logger.Debug(name, c.Logger.Field("response", resp.Body()), c.Logger.Field("componentAppId", c.AppId))
+		if attempt > 0 || !isTokenExpired(resp.Body()) {
+			return resp, nil
+		}
+		if err := c.resetAccessToken(accessToken.AccessToken); err != nil {
+			return nil, err
+		}
+		c.Logger.Warn(name+" retry", c.Logger.Field("response", resp.Body()), c.Logger.Field("componentAppId", c.AppId))
+	}
+}
+
+func (c *Component) post(name string, url string, body interface{}, res interface{}) error {
+	resp, err := c.request(name, "POST", url, "component_access_token", nil, body)
+	if err != nil {
+		return err
+	}
+	return decodeRes(name, resp.Body(), res)
+}
+
+func (c *Component) wxaGet(name string, url string, params map[string]string, res interface{}) error {
+	resp, err := c.request(name, "GET", url, "access_token", params, nil)
+	if err != nil {
+		return err
+	}
+	return decodeRes(name, resp.Body(), res)
+}
+
+func (c *Component) wxaPost(name string, url string, body interface{}, res interface{}) error {
+	resp, err := c.request(name, "POST", url, "access_token", nil, body)
+	if err != nil {
+		return err
+	}
+	return decodeRes(name, resp.Body(), res)
+}
+
+func (c *Component) authorizerRequest(name string, authorizerAppId string, method string, url string, params map[string]string, body interface{}) (*resty.Response, error) {
+	for attempt := 0; ; attempt++ {
+		accessToken, _, err := c.GetAuthorizerAccessToken(authorizerAppId)
+		if err != nil {
+			return nil, err
+		}
+
+		req := resty.New().R().
+			SetQueryParams(params).
+			SetQueryParam("access_token", accessToken)
+		if body != nil {
+			req.SetBody(body)
+		}
+		resp, err := req.Execute(method, url)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Logger.Debug(name, c.Logger.Field("response", resp.Body()), c.Logger.Field("authorizerAppId", authorizerAppId), c.Logger.Field("componentAppId", c.AppId))
+		if attempt > 0 || !isTokenExpired(resp.Body()) {
+			return resp, nil
+		}
+		if err := c.resetAuthorizerAccessToken(authorizerAppId, accessToken); err != nil {
+			return nil, err
+		}
+		c.Logger.Warn(name+" retry", c.Logger.Field("response", resp.Body()), c.Logger.Field("authorizerAppId", authorizerAppId), c.Logger.Field("componentAppId", c.AppId))
+	}
+}
+
+func (c *Component) authorizerGet(name string, authorizerAppId string, url string, params map[string]string, res interface{}) error {
+	resp, err := c.authorizerRequest(name, authorizerAppId, "GET", url, params, nil)
+	if err != nil {
+		return err
+	}
+	return decodeRes(name, resp.Body(), res)
+}
+
+func (c *Component) authorizerPost(name string, authorizerAppId string, url string, body interface{}, res interface{}) error {
+	resp, err := c.authorizerRequest(name, authorizerAppId, "POST", url, nil, body)
+	if err != nil {
+		return err
+	}
+	return decodeRes(name, resp.Body(), res)
+}
@@ -0,0 +1,307 @@
+package component
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/go-tron/logger"
+	"github.com/go-tron/redis"
+	"github.com/go-tron/weixin"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var component = New(&Config{
+	AppId:          "wx304925fbea25bcbe",
+	AppSecret:      "1d2c3b4a5e6f7a8b9c0d1e2f3a4b5c6d",
+	Token:          "tKGWvgmHsQWLv3z1",
+	EncodingAESKey: "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG",
+	Logger:         logger.NewZap("weixin-component", "info"),
+	Redis: redis.New(&redis.Config{
+		Addr:     "127.0.0.1:6379",
+		Password: "GBkrIO9bkOcWrdsC",
+	}),
+})
+
+func TestCrypt(t *testing.T) {
+	encrypt, err := Encrypt(component.EncodingAESKey, component.AppId, []byte("<xml></xml>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, appId, err := Decrypt(component.EncodingAESKey, encrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "<xml></xml>" || appId != component.AppId {
+		t.Fatal("result", string(data), appId)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	encrypt, err := Encrypt(component.EncodingAESKey, component.AppId, []byte("<xml></xml>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flips a padding byte of the last block without touching the final one
+	data[len(data)-18] ^= 0x01
+	if _, _, err := Decrypt(component.EncodingAESKey, base64.StdEncoding.EncodeToString(data)); err == nil {
+		t.Fatal("tampered ciphertext accepted")
+	}
+}
+
+func TestHandleVerifyTicket(t *testing.T) {
+	encrypt, err := Encrypt(component.EncodingAESKey, component.AppId, []byte(`<xml><AppId><![CDATA[wx304925fbea25bcbe]]></AppId><CreateTime>1413192605</CreateTime><InfoType><![CDATA[component_verify_ticket]]></InfoType><ComponentVerifyTicket><![CDATA[ticket@@@lEHjsBEi_TPDey0IZxw4Zbb7JRYLOtEf9ksvDpSwzkwog3R6xEpdaK0yIkCQVBVoN]]></ComponentVerifyTicket></xml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `<xml><AppId><![CDATA[wx304925fbea25bcbe]]></AppId><Encrypt><![CDATA[` + encrypt + `]]></Encrypt></xml>`
+	signature := Signature(component.Token, "1413192605", "1320562132", encrypt)
+
+	notify, err := component.ParseNotify(httptest.NewRequest("POST", "/component/notify?timestamp=1413192605&nonce=1320562132&msg_signature="+signature, bytes.NewReader([]byte(body))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notify.InfoType != InfoTypeComponentVerifyTicket {
+		t.Fatal("notify", notify)
+	}
+	if err := component.HandleNotify(notify); err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := component.GetVerifyTicket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket != notify.ComponentVerifyTicket {
+		t.Fatal("ticket", ticket)
+	}
+
+	if _, err := component.ParseNotify(httptest.NewRequest("POST", "/component/notify?timestamp=1413192605&nonce=1320562132&msg_signature=invalid", bytes.NewReader([]byte(body)))); err == nil {
+		t.Fatal("invalid signature accepted")
+	}
+}
+
+func TestGetAuthUrl(t *testing.T) {
+	result, err := component.GetAuthUrl(&AuthUrlReq{
+		RedirectUri: "https://weixin.eioos.com/component/auth",
+		AuthType:    AuthTypeOfficialAccount,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", result)
+}
+
+func TestTokenSource(t *testing.T) {
+	if err := component.saveAuthorizerToken("wxf8b4f85f3a794e77", "authorizer-access-token", 7200, "refreshtoken@@@RU0Sgi7bD6apS7frS9gj8Sbws7OoDejK9Z-cm0EnCzg"); err != nil {
+		t.Fatal(err)
+	}
+	wx := weixin.New(&weixin.Config{
+		Name:        "authorizer",
+		AppId:       "wxf8b4f85f3a794e77",
+		Logger:      component.Logger,
+		Redis:       component.Redis,
+		TokenSource: component.TokenSource(),
+	})
+	result, err := wx.GetAccessToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AccessToken != "authorizer-access-token" || result.ExpiresIn <= 0 {
+		t.Fatal("result", result)
+	}
+	authorized, err := component.Authorized("wxf8b4f85f3a794e77")
+	if err != nil || !authorized {
+		t.Fatal("authorized", authorized, err)
+	}
+	if err := component.HandleNotify(&Notify{InfoType: InfoTypeUnauthorized, AuthorizerAppId: "wxf8b4f85f3a794e77"}); err != nil {
+		t.Fatal(err)
+	}
+	if authorized, _ := component.Authorized("wxf8b4f85f3a794e77"); authorized {
+		t.Fatal("unauthorized authorizer still authorized")
+	}
+}
+
+func newTestComponent(t *testing.T, handler http.Handler) *Component {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	config := *component.Config
+	config.BaseUrl = ts.URL
+	c := New(&config)
+	c.SetAccessToken("component-access-token", 7200)
+	return c
+}
+
+func testAuthorizerTokenHandler(calls *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		time.Sleep(time.Millisecond * 50)
+		json.NewEncoder(w).Encode(&AuthorizerTokenRes{
+			AuthorizerAccessToken:  fmt.Sprintf("authorizer-access-token-%d", n),
+			ExpiresIn:              7200,
+			AuthorizerRefreshToken: "refreshtoken",
+		})
+	}
+}
+
+func TestGetAuthorizerAccessTokenConcurrent(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.Handle("/cgi-bin/component/api_authorizer_token", testAuthorizerTokenHandler(&calls))
+	// two instances sharing redis stand in for two processes
+	instances := []*Component{newTestComponent(t, mux), newTestComponent(t, mux)}
+	if err := instances[0].saveAuthorizerToken("wxconcurrent", "expired", 1, "refreshtoken"); err != nil {
+		t.Fatal(err)
+	}
+	if err := instances[0].ResetAuthorizerAccessToken("wxconcurrent"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	tokens := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(c *Component) {
+			defer wg.Done()
+			accessToken, _, err := c.GetAuthorizerAccessToken("wxconcurrent")
+			if err != nil {
+				t.Error(err)
+			}
+			tokens <- accessToken
+		}(instances[i%2])
+	}
+	wg.Wait()
+	close(tokens)
+
+	if calls != 1 {
+		t.Fatal("refresh calls", calls)
+	}
+	for accessToken := range tokens {
+		if accessToken != "authorizer-access-token-1" {
+			t.Fatal("accessToken", accessToken)
+		}
+	}
+}
+
+func TestAuthorizerRequestRetry(t *testing.T) {
+	var calls, releases int32
+	mux := http.NewServeMux()
+	mux.Handle("/cgi-bin/component/api_authorizer_token", testAuthorizerTokenHandler(&calls))
+	mux.HandleFunc("/wxa/release", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&releases, 1)
+		if r.URL.Query().Get("access_token") == "revoked" {
+			w.Write([]byte(`{"errcode":40001,"errmsg":"invalid credential"}`))
+			return
+		}
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	})
+	c := newTestComponent(t, mux)
+	if err := c.saveAuthorizerToken("wxretry", "revoked", 7200, "refreshtoken"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Release("wxretry"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || releases != 2 {
+		t.Fatal("calls", calls, releases)
+	}
+	accessToken, _, err := c.GetAuthorizerAccessToken("wxretry")
+	if err != nil || accessToken != "authorizer-access-token-1" {
+		t.Fatal("accessToken", accessToken, err)
+	}
+}
+
+func TestAccountsGetAccountById(t *testing.T) {
+	var calls int32
+	c := newTestComponent(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	if err := c.saveAuthorizerToken("wxaccount", "authorizer-access-token", 7200, "refreshtoken"); err != nil {
+		t.Fatal(err)
+	}
+	accounts := NewAccounts(c)
+	account, err := accounts.GetAccountById("wxaccount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Name != "wxaccount" || calls != 0 {
+		t.Fatal("account", account.Name, calls)
+	}
+	if cached, _ := accounts.GetAccountById("wxaccount"); cached != account {
+		t.Fatal("account not cached")
+	}
+}
+
+func TestHandleUnauthorizedEvictsAccount(t *testing.T) {
+	c := newTestComponent(t, http.NotFoundHandler())
+	if err := c.saveAuthorizerToken("wxunauthorized", "authorizer-access-token", 7200, "refreshtoken"); err != nil {
+		t.Fatal(err)
+	}
+	accounts := NewAccounts(c)
+	baseAccounts := NewBaseAccounts(c)
+	if _, err := accounts.GetAccountById("wxunauthorized"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := baseAccounts.GetAccountById("wxunauthorized"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.HandleNotify(&Notify{InfoType: InfoTypeUnauthorized, AuthorizerAppId: "wxunauthorized"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := accounts.GetAccountById("wxunauthorized"); err == nil {
+		t.Fatal("unauthorized account still served")
+	}
+	if _, err := baseAccounts.GetAccountById("wxunauthorized"); err == nil {
+		t.Fatal("unauthorized base account still served")
+	}
+}
+
+func TestComponentRequestRetry(t *testing.T) {
+	var calls, requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cgi-bin/component/api_component_token", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"component_access_token":"component-access-token-fresh","expires_in":7200}`))
+	})
+	mux.HandleFunc("/cgi-bin/component/api_get_authorizer_info", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Query().Get("component_access_token") == "revoked" {
+			w.Write([]byte(`{"errcode":40001,"errmsg":"invalid credential"}`))
+			return
+		}
+		w.Write([]byte(`{"authorizer_info":{"nick_name":"authorizer"}}`))
+	})
+	c := newTestComponent(t, mux)
+	c.SetAccessToken("revoked", 7200)
+	if err := c.Redis.Set(context.Background(), AccessTokenPrefix+c.AppId, "revoked", time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetVerifyTicket("ticket"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := c.GetAuthorizerInfo("wxauthorizer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.AuthorizerInfo.NickName != "authorizer" || calls != 1 || requests != 2 {
+		t.Fatal("result", info.AuthorizerInfo, calls, requests)
+	}
+	accessToken, err := c.GetAccessToken()
+	if err != nil || accessToken.AccessToken != "component-access-token-fresh" {
+		t.Fatal("accessToken", accessToken, err)
+	}
+}
@@ -0,0 +1,93 @@
+package component
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"github.com/go-tron/random"
+	"sort"
+	"strings"
+)
+
+func Signature(token string, timestamp string, nonce string, encrypt string) string {
+	values := []string{token, timestamp, nonce, encrypt}
+	sort.Strings(values)
+	hash := sha1.Sum([]byte(strings.Join(values, "")))
+	return hex.EncodeToString(hash[:])
+}
+
+func aesKey(encodingAESKey string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encodingAESKey + "=")
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, errors.New("EncodingAESKey invalid")
+	}
+	return key, nil
+}
+
+func Decrypt(encodingAESKey string, encrypt string) ([]byte, string, error) {
+	key, err := aesKey(encodingAESKey)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypt)
+	if err != nil {
+		return nil, "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, "", errors.New("encrypt invalid")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, key[:block.BlockSize()]).CryptBlocks(plain, data)
+
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > 32 || padding > len(plain) {
+		return nil, "", errors.New("encrypt padding invalid")
+	}
+	if !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, "", errors.New("encrypt padding invalid")
+	}
+	plain = plain[:len(plain)-padding]
+	if len(plain) < 20 {
+		return nil, "", errors.New("encrypt invalid")
+	}
+	length := int(binary.BigEndian.Uint32(plain[16:20]))
+	if 20+length > len(plain) {
+		return nil, "", errors.New("encrypt length invalid")
+	}
+	return plain[20 : 20+length], string(plain[20+length:]), nil
+}
+
+func Encrypt(encodingAESKey string, appId string, msg []byte) (string, error) {
+	key, err := aesKey(encodingAESKey)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(random.String(16))
+	binary.Write(&buf, binary.BigEndian, uint32(len(msg)))
+	buf.Write(msg)
+	buf.WriteString(appId)
+
+	padding := 32 - buf.Len()%32
+	buf.Write(bytes.Repeat([]byte{byte(padding)}, padding))
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, buf.Len())
+	cipher.NewCBCEncrypter(block, key[:block.BlockSize()]).CryptBlocks(data, buf.Bytes())
+	return base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,103 @@
+package component
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type InfoType string
+
+const (
+	InfoTypeComponentVerifyTicket InfoType = "component_verify_ticket"
+	InfoTypeAuthorized            InfoType = "authorized"
+	InfoTypeUpdateAuthorized      InfoType = "updateauthorized"
+	InfoTypeUnauthorized          InfoType = "unauthorized"
+)
+
+type EncryptMessage struct {
+	XMLName    xml.Name `xml:"xml"`
+	AppId      string   `xml:"AppId"`
+	ToUserName string   `xml:"ToUserName"`
+	Encrypt    string   `xml:"Encrypt"`
+}
+
+type Notify struct {
+	XMLName                      xml.Name `xml:"xml"`
+	AppId                        string   `xml:"AppId"`
+	CreateTime                   int64    `xml:"CreateTime"`
+	InfoType                     InfoType `xml:"InfoType"`
+	ComponentVerifyTicket        string   `xml:"ComponentVerifyTicket"`
+	AuthorizerAppId              string   `xml:"AuthorizerAppid"`
+	AuthorizationCode            string   `xml:"AuthorizationCode"`
+	AuthorizationCodeExpiredTime int64    `xml:"AuthorizationCodeExpiredTime"`
+	PreAuthCode                  string   `xml:"PreAuthCode"`
+}
+
+func (c *Component) DecryptMessage(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var msg = &EncryptMessage{}
+	if err := xml.Unmarshal(body, msg); err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	if Signature(c.Token, query.Get("timestamp"), query.Get("nonce"), msg.Encrypt) != query.Get("msg_signature") {
+		return nil, errors.New("msg_signature invalid")
+	}
+	data, appId, err := Decrypt(c.EncodingAESKey, msg.Encrypt)
+	if err != nil {
+		return nil, err
+	}
+	if appId != c.AppId {
+		return nil, errors.New("appId mismatch:" + appId)
+	}
+	c.Logger.Debug("DecryptMessage", c.Logger.Field("message", data), c.Logger.Field("componentAppId", c.AppId))
+	return data, nil
+}
+
+func (c *Component) ParseNotify(r *http.Request) (*Notify, error) {
+	data, err := c.DecryptMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	var notify = &Notify{}
+	if err := xml.Unmarshal(data, notify); err != nil {
+		return nil, err
+	}
+	return notify, nil
+}
+
+func (c *Component) HandleNotify(notify *Notify) error {
+	switch notify.InfoType {
+	case InfoTypeComponentVerifyTicket:
+		return c.SetVerifyTicket(notify.ComponentVerifyTicket)
+	case InfoTypeAuthorized, InfoTypeUpdateAuthorized:
+		_, err := c.QueryAuth(notify.AuthorizationCode)
+		return err
+	case InfoTypeUnauthorized:
+		if err := c.ClearAuthorizer(notify.AuthorizerAppId); err != nil {
+			return err
+		}
+		c.unauthorized(notify.AuthorizerAppId)
+	}
+	return nil
+}
+
+func (c *Component) OnUnauthorized(hook func(authorizerAppId string)) {
+	c.unauthorizedLock.Lock()
+	defer c.unauthorizedLock.Unlock()
+	c.unauthorizedHooks = append(c.unauthorizedHooks, hook)
+}
+
+func (c *Component) unauthorized(authorizerAppId string) {
+	c.unauthorizedLock.Lock()
+	hooks := c.unauthorizedHooks
+	c.unauthorizedLock.Unlock()
+	for _, hook := range hooks {
+		hook(authorizerAppId)
+	}
+}
@@ -0,0 +1,270 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-tron/redis"
+	"sync"
+	"time"
+)
+
+const (
+	RolloutPrefix = "wx-component-rollout:"
+	RolloutExpire = time.Hour * 24 * 30
+)
+
+type RolloutStage string
+
+const (
+	RolloutStagePending       RolloutStage = "pending"
+	RolloutStageAuditing      RolloutStage = "auditing"
+	RolloutStageAuditRejected RolloutStage = "audit_rejected"
+	RolloutStageAudited       RolloutStage = "audited"
+	RolloutStageReleased      RolloutStage = "released"
+	RolloutStageGrayReleased  RolloutStage = "gray_released"
+	RolloutStageFailed        RolloutStage = "failed"
+)
+
+func (s RolloutStage) Final() bool {
+	return s == RolloutStageReleased || s == RolloutStageGrayReleased || s == RolloutStageAuditRejected
+}
+
+// without AutoRelease an audited app waits for a manual Release, with
+// RetryRejected a rejected app is committed and submitted again
+func (s RolloutStage) done(autoRelease bool, retryRejected bool) bool {
+	if s == RolloutStageAuditRejected {
+		return !retryRejected
+	}
+	return s.Final() || (s == RolloutStageAudited && !autoRelease)
+}
+
+type RolloutState struct {
+	AppId     string       `json:"appId"`
+	Stage     RolloutStage `json:"stage"`
+	AuditId   int64        `json:"auditId"`
+	Reason    string       `json:"reason"`
+	Error     string       `json:"error"`
+	UpdatedAt int64        `json:"updatedAt"`
+}
+
+type RolloutStore interface {
+	GetRolloutStates(rolloutId string) (map[string]*RolloutState, error)
+	SaveRolloutState(rolloutId string, state *RolloutState) error
+}
+
+func NewRedisRolloutStore(redis *redis.Redis, componentAppId string) RolloutStore {
+	return &RedisRolloutStore{
+		Redis:          redis,
+		ComponentAppId: componentAppId,
+	}
+}
+
+type RedisRolloutStore struct {
+	Redis          *redis.Redis
+	ComponentAppId string
+}
+
+func (s *RedisRolloutStore) GetRolloutStates(rolloutId string) (map[string]*RolloutState, error) {
+	values, err := s.Redis.HGetAll(context.Background(), RolloutPrefix+s.ComponentAppId+":"+rolloutId).Result()
+	if err != nil {
+		return nil, err
+	}
+	var states = make(map[string]*RolloutState, len(values))
+	for appId, value := range values {
+		var state = &RolloutState{}
+		if err := json.Unmarshal([]byte(value), state); err != nil {
+			return nil, err
+		}
+		states[appId] = state
+	}
+	return states, nil
+}
+
+func (s *RedisRolloutStore) SaveRolloutState(rolloutId string, state *RolloutState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	key := RolloutPrefix + s.ComponentAppId + ":" + rolloutId
+	pipe := s.Redis.TxPipeline()
+	pipe.HSet(context.Background(), key, state.AppId, data)
+	pipe.Expire(context.Background(), key, RolloutExpire)
+	_, err = pipe.Exec(context.Background())
+	return err
+}
+
+type RolloutReq struct {
+	RolloutId      string                         `json:"rolloutId"`
+	AppIds         []string                       `json:"appIds"`
+	Code           *CommitCodeReq                 `json:"code"`
+	ExtJson        func(appId string) interface{} `json:"-"`
+	Audit          *SubmitAuditReq                `json:"audit"`
+	AutoRelease    bool                           `json:"autoRelease"`
+	RetryRejected  bool                           `json:"retryRejected"`
+	GrayPercentage int                            `json:"grayPercentage"`
+	Workers        int                            `json:"workers"`
+	Store          RolloutStore                   `json:"-"`
+}
+
+type RolloutReport struct {
+	RolloutId     string                   `json:"rolloutId"`
+	Total         int                      `json:"total"`
+	AutoRelease   bool                     `json:"autoRelease"`
+	RetryRejected bool                     `json:"retryRejected"`
+	Stages        map[RolloutStage]int     `json:"stages"`
+	States        map[string]*RolloutState `json:"states"`
+	Duration      time.Duration            `json:"duration"`
+}
+
+func (r *RolloutReport) Completed() bool {
+	for _, state := range r.States {
+		if !state.Stage.done(r.AutoRelease, r.RetryRejected) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Component) advanceRollout(ctx context.Context, params *RolloutReq, state *RolloutState) {
+	if ctx.Err() != nil {
+		return
+	}
+	var err error
+	switch state.Stage {
+	case "", RolloutStagePending, RolloutStageFailed, RolloutStageAuditRejected:
+		state.Stage = RolloutStagePending
+		code := *params.Code
+		if params.ExtJson != nil {
+			code.ExtJson = params.ExtJson(state.AppId)
+		}
+		if err = c.CommitCode(state.AppId, &code); err != nil {
+			break
+		}
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		var auditId int64
+		if auditId, err = c.SubmitAudit(state.AppId, params.Audit); err != nil {
+			break
+		}
+		state.Stage = RolloutStageAuditing
+		state.AuditId = auditId
+		state.Reason = ""
+	case RolloutStageAuditing:
+		var res *AuditStatusRes
+		if res, err = c.GetAuditStatus(state.AppId, state.AuditId); err != nil {
+			break
+		}
+		switch res.Status {
+		case AuditStatusSuccess:
+			state.Stage = RolloutStageAudited
+		case AuditStatusRejected, AuditStatusWithdrawn:
+			state.Stage = RolloutStageAuditRejected
+			state.Reason = res.Reason
+		}
+	}
+	if err == nil && state.Stage == RolloutStageAudited && params.AutoRelease {
+		switch {
+		case ctx.Err() != nil:
+			err = ctx.Err()
+		case params.GrayPercentage > 0 && params.GrayPercentage < 100:
+			if err = c.GrayRelease(state.AppId, params.GrayPercentage); err == nil {
+				state.Stage = RolloutStageGrayReleased
+			}
+		default:
+			if err = c.Release(state.AppId); err == nil {
+				state.Stage = RolloutStageReleased
+			}
+		}
+	}
+
+	state.Error = ""
+	if err != nil {
+		if state.Stage == RolloutStagePending {
+			state.Stage = RolloutStageFailed
+		}
+		state.Error = err.Error()
+		c.Logger.Warn("Rollout", c.Logger.Field("error", err), c.Logger.Field("stage", state.Stage), c.Logger.Field("authorizerAppId", state.AppId), c.Logger.Field("componentAppId", c.AppId))
+	}
+	state.UpdatedAt = time.Now().Unix()
+}
+
+func (c *Component) Rollout(ctx context.Context, params *RolloutReq) (*RolloutReport, error) {
+	if params.RolloutId == "" {
+		return nil, errors.New("rolloutId 必须设置")
+	}
+	if params.Code == nil {
+		return nil, errors.New("code 必须设置")
+	}
+	workers := params.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+	store := params.Store
+	if store == nil {
+		store = NewRedisRolloutStore(c.Redis, c.AppId)
+	}
+
+	states, err := store.GetRolloutStates(params.RolloutId)
+	if err != nil {
+		return nil, err
+	}
+	startedAt := time.Now()
+	report := &RolloutReport{
+		RolloutId:     params.RolloutId,
+		Total:         len(params.AppIds),
+		AutoRelease:   params.AutoRelease,
+		RetryRejected: params.RetryRejected,
+		Stages:        make(map[RolloutStage]int),
+		States:        make(map[string]*RolloutState, len(params.AppIds)),
+	}
+	for _, appId := range params.AppIds {
+		state := states[appId]
+		if state == nil {
+			state = &RolloutState{AppId: appId, Stage: RolloutStagePending}
+		}
+		report.States[appId] = state
+	}
+
+	jobs := make(chan *RolloutState)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for state := range jobs {
+				c.advanceRollout(ctx, params, state)
+				if err := store.SaveRolloutState(params.RolloutId, state); err != nil {
+					c.Logger.Error("Rollout store", c.Logger.Field("error", err), c.Logger.Field("rolloutId", params.RolloutId), c.Logger.Field("componentAppId", c.AppId))
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, appId := range params.AppIds {
+		state := report.States[appId]
+		if state.Stage.done(params.AutoRelease, params.RetryRejected) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- state:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, state := range report.States {
+		report.Stages[state.Stage]++
+	}
+	report.Duration = time.Since(startedAt)
+	c.Logger.Info("Rollout",
+		c.Logger.Field("rolloutId", report.RolloutId),
+		c.Logger.Field("total", report.Total),
+		c.Logger.Field("stages", report.Stages),
+		c.Logger.Field("componentAppId", c.AppId))
+	return report, ctx.Err()
+}
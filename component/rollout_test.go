@@ -0,0 +1,229 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testWxaServer struct {
+	lock       sync.Mutex
+	commitFail map[string]int
+	commitHook func(appId string)
+	audit      map[string]*AuditStatusRes
+	calls      map[string]int
+}
+
+func (s *testWxaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	appId := strings.TrimPrefix(r.URL.Query().Get("access_token"), "token-")
+	s.calls[r.URL.Path+":"+appId]++
+
+	var res interface{} = map[string]interface{}{"errcode": 0, "errmsg": "ok"}
+	switch r.URL.Path {
+	case "/wxa/commit":
+		if s.commitHook != nil {
+			s.commitHook(appId)
+		}
+		if s.commitFail[appId] > 0 {
+			s.commitFail[appId]--
+			res = map[string]interface{}{"errcode": 85013, "errmsg": "无效的自定义配置"}
+		}
+	case "/wxa/submit_audit":
+		res = map[string]interface{}{"errcode": 0, "auditid": len(s.audit) + 1000}
+		s.audit[appId] = &AuditStatusRes{Status: AuditStatusAuditing}
+	case "/wxa/get_auditstatus":
+		res = s.audit[appId]
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func newTestRollout(t *testing.T, rolloutId string, appIds ...string) (*Component, *testWxaServer) {
+	server := &testWxaServer{
+		commitFail: make(map[string]int),
+		audit:      make(map[string]*AuditStatusRes),
+		calls:      make(map[string]int),
+	}
+	c := newTestComponent(t, server)
+	for _, appId := range appIds {
+		if err := c.saveAuthorizerToken(appId, "token-"+appId, 7200, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	key := RolloutPrefix + c.AppId + ":" + rolloutId
+	if _, err := c.Redis.Del(context.Background(), key).Result(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Redis.Del(context.Background(), key)
+	})
+	return c, server
+}
+
+func TestRolloutStateMachine(t *testing.T) {
+	c, server := newTestRollout(t, "rollout-state", "wxa", "wxb", "wxc")
+	server.commitFail["wxc"] = 1
+	params := &RolloutReq{
+		RolloutId:   "rollout-state",
+		AppIds:      []string{"wxa", "wxb", "wxc"},
+		Code:        &CommitCodeReq{TemplateId: "1", UserVersion: "1.0.0"},
+		AutoRelease: true,
+	}
+
+	report, err := c.Rollout(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Completed() || report.Stages[RolloutStageAuditing] != 2 || report.States["wxc"].Stage != RolloutStageFailed || report.States["wxc"].Error == "" {
+		t.Fatal("report", report.Stages, report.States["wxc"])
+	}
+
+	server.audit["wxa"].Status = AuditStatusSuccess
+	server.audit["wxb"].Status = AuditStatusRejected
+	server.audit["wxb"].Reason = "类目不符"
+	report, err = c.Rollout(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.States["wxa"].Stage != RolloutStageReleased || report.States["wxb"].Stage != RolloutStageAuditRejected || report.States["wxb"].Reason != "类目不符" {
+		t.Fatal("report", report.Stages)
+	}
+	if report.States["wxc"].Stage != RolloutStageAuditing || report.States["wxc"].Error != "" || server.calls["/wxa/commit:wxc"] != 2 {
+		t.Fatal("resume", report.States["wxc"], server.calls)
+	}
+
+	server.audit["wxc"].Status = AuditStatusSuccess
+	params.GrayPercentage = 10
+	report, err = c.Rollout(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Completed() || report.States["wxc"].Stage != RolloutStageGrayReleased {
+		t.Fatal("report", report.Stages)
+	}
+	for key, n := range map[string]int{
+		"/wxa/commit:wxa":          1,
+		"/wxa/submit_audit:wxc":    1,
+		"/wxa/release:wxa":         1,
+		"/wxa/grayrelease:wxc":     1,
+		"/wxa/release:wxc":         0,
+		"/wxa/get_auditstatus:wxb": 1,
+	} {
+		if server.calls[key] != n {
+			t.Fatal("calls", key, server.calls[key])
+		}
+	}
+}
+
+func TestRolloutManualRelease(t *testing.T) {
+	c, server := newTestRollout(t, "rollout-manual", "wxd")
+	params := &RolloutReq{
+		RolloutId: "rollout-manual",
+		AppIds:    []string{"wxd"},
+		Code:      &CommitCodeReq{TemplateId: "1", UserVersion: "1.0.0"},
+	}
+	for i := 0; i < 3; i++ {
+		report, err := c.Rollout(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if report.Completed() {
+				t.Fatal("report", report.Stages)
+			}
+			server.audit["wxd"].Status = AuditStatusSuccess
+			continue
+		}
+		if !report.Completed() || report.States["wxd"].Stage != RolloutStageAudited {
+			t.Fatal("report", report.Stages)
+		}
+	}
+	if server.calls["/wxa/get_auditstatus:wxd"] != 1 || server.calls["/wxa/release:wxd"] != 0 {
+		t.Fatal("calls", server.calls)
+	}
+}
+
+func TestRolloutResume(t *testing.T) {
+	c, server := newTestRollout(t, "rollout-resume", "wxe", "wxf")
+	store := NewRedisRolloutStore(c.Redis, c.AppId)
+	for _, state := range []*RolloutState{
+		{AppId: "wxe", Stage: RolloutStageReleased, AuditId: 1234567},
+		{AppId: "wxf", Stage: RolloutStageAuditRejected, AuditId: 1234568, Reason: "类目不符"},
+	} {
+		if err := store.SaveRolloutState("rollout-resume", state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	params := &RolloutReq{
+		RolloutId:   "rollout-resume",
+		AppIds:      []string{"wxe", "wxf"},
+		Code:        &CommitCodeReq{TemplateId: "1", UserVersion: "1.0.0"},
+		AutoRelease: true,
+	}
+	report, err := c.Rollout(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Completed() || report.Stages[RolloutStageReleased] != 1 || report.States["wxf"].Reason != "类目不符" || len(server.calls) != 0 {
+		t.Fatal("report", report.Stages, server.calls)
+	}
+	if params.Workers != 0 {
+		t.Fatal("params modified", params.Workers)
+	}
+
+	params.RetryRejected = true
+	report, err = c.Rollout(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Completed() || report.States["wxf"].Stage != RolloutStageAuditing || report.States["wxf"].Reason != "" {
+		t.Fatal("report", report.States["wxf"])
+	}
+	if server.calls["/wxa/submit_audit:wxf"] != 1 || server.calls["/wxa/commit:wxe"] != 0 {
+		t.Fatal("calls", server.calls)
+	}
+}
+
+func TestRolloutCancel(t *testing.T) {
+	c, server := newTestRollout(t, "rollout-cancel", "wxg")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.commitHook = func(appId string) {
+		cancel()
+	}
+
+	report, err := c.Rollout(ctx, &RolloutReq{
+		RolloutId: "rollout-cancel",
+		AppIds:    []string{"wxg"},
+		Code:      &CommitCodeReq{TemplateId: "1", UserVersion: "1.0.0"},
+	})
+	if err != context.Canceled {
+		t.Fatal("err", err)
+	}
+	if report.States["wxg"].Stage != RolloutStageFailed || server.calls["/wxa/submit_audit:wxg"] != 0 {
+		t.Fatal("report", report.States["wxg"], server.calls)
+	}
+}
+
+func TestGetTemplateList(t *testing.T) {
+	c := newTestComponent(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wxa/gettemplatelist" || r.URL.Query().Get("template_type") != "0" {
+			t.Error("request", r.URL)
+		}
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok","template_list":[{"create_time":1488965944,"user_version":"1.0.0","template_id":1}]}`))
+	}))
+	templateType := TemplateTypeNormal
+	result, err := c.GetTemplateList(&templateType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].UserVersion != "1.0.0" {
+		t.Fatal("result", result)
+	}
+}
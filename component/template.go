@@ -0,0 +1,73 @@
+package component
+
+import (
+	"strconv"
+)
+
+type TemplateType int
+
+const (
+	TemplateTypeNormal   TemplateType = 0
+	TemplateTypeStandard TemplateType = 1
+)
+
+type TemplateDraft struct {
+	CreateTime  int64  `json:"create_time"`
+	UserVersion string `json:"user_version"`
+	UserDesc    string `json:"user_desc"`
+	DraftId     int64  `json:"draft_id"`
+}
+
+type GetTemplateDraftListRes struct {
+	DraftList []*TemplateDraft `json:"draft_list"`
+}
+
+func (c *Component) GetTemplateDraftList() ([]*TemplateDraft, error) {
+	var res = &GetTemplateDraftListRes{}
+	if err := c.wxaGet("GetTemplateDraftList", c.BaseUrl+"/wxa/gettemplatedraftlist", nil, res); err != nil {
+		return nil, err
+	}
+	return res.DraftList, nil
+}
+
+func (c *Component) AddToTemplate(draftId int64, templateType TemplateType) error {
+	return c.wxaPost("AddToTemplate", c.BaseUrl+"/wxa/addtotemplate", map[string]int64{
+		"draft_id":      draftId,
+		"template_type": int64(templateType),
+	}, nil)
+}
+
+type CodeTemplate struct {
+	CreateTime             int64        `json:"create_time"`
+	UserVersion            string       `json:"user_version"`
+	UserDesc               string       `json:"user_desc"`
+	TemplateId             int64        `json:"template_id"`
+	TemplateType           TemplateType `json:"template_type"`
+	SourceMiniProgramAppId string       `json:"source_miniprogram_appid"`
+	SourceMiniProgram      string       `json:"source_miniprogram"`
+	Developer              string       `json:"developer"`
+}
+
+type GetTemplateListRes struct {
+	TemplateList []*CodeTemplate `json:"template_list"`
+}
+
+func (c *Component) GetTemplateList(templateType *TemplateType) ([]*CodeTemplate, error) {
+	var params map[string]string
+	if templateType != nil {
+		params = map[string]string{
+			"template_type": strconv.Itoa(int(*templateType)),
+		}
+	}
+	var res = &GetTemplateListRes{}
+	if err := c.wxaGet("GetTemplateList", c.BaseUrl+"/wxa/gettemplatelist", params, res); err != nil {
+		return nil, err
+	}
+	return res.TemplateList, nil
+}
+
+func (c *Component) DeleteTemplate(templateId int64) error {
+	return c.wxaPost("DeleteTemplate", c.BaseUrl+"/wxa/deletetemplate", map[string]int64{
+		"template_id": templateId,
+	}, nil)
+}
@@ -0,0 +1,179 @@
+package weixin
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	localTime "github.com/go-tron/local-time"
+	"time"
+)
+
+var WatermarkExpire = time.Hour
+
+type Watermark struct {
+	AppId     string `json:"appid"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func (w *Watermark) Verify(appId string) error {
+	if w.AppId != appId {
+		return errors.New("watermark appid invalid")
+	}
+	if w.Timestamp == 0 {
+		return errors.New("watermark timestamp invalid")
+	}
+	if localTime.Since(localTime.Unix(w.Timestamp, 0)) > WatermarkExpire {
+		return errors.New("watermark expired")
+	}
+	return nil
+}
+
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, errors.New("invalid padding")
+	}
+	if !bytes.Equal(data[len(data)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, errors.New("invalid padding")
+	}
+	return data[:len(data)-n], nil
+}
+
+func Decrypt(sessionKey string, encryptedData string, iv string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(encryptedData)
+	if err != nil {
+		return nil, err
+	}
+	ivBytes, err := base64.StdEncoding.DecodeString(iv)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 16 || len(ivBytes) != aes.BlockSize {
+		return nil, errors.New("sessionKey or iv invalid")
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("encryptedData invalid")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, ivBytes).CryptBlocks(plain, data)
+	return pkcs7Unpad(plain, aes.BlockSize)
+}
+
+type EncryptedDataReq struct {
+	OpenId        string `json:"openId"`
+	SessionKey    string `json:"sessionKey"`
+	EncryptedData string `json:"encryptedData"`
+	Iv            string `json:"iv"`
+}
+
+func (wx *Weixin) DecryptData(params *EncryptedDataReq, v interface{}) error {
+	sessionKey := params.SessionKey
+	if sessionKey == "" {
+		key, err := wx.GetSessionKey(params.OpenId)
+		if err != nil {
+			return err
+		}
+		sessionKey = key
+	}
+
+	data, err := Decrypt(sessionKey, params.EncryptedData, params.Iv)
+	if err != nil {
+		return err
+	}
+
+	var watermarked = &struct {
+		Watermark Watermark `json:"watermark"`
+	}{}
+	if err := json.Unmarshal(data, watermarked); err != nil {
+		return err
+	}
+	if err := watermarked.Watermark.Verify(wx.AppId); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+type DecryptedUserInfo struct {
+	OpenId    string    `json:"openId"`
+	UnionId   string    `json:"unionId"`
+	NickName  string    `json:"nickName"`
+	Gender    int       `json:"gender"`
+	Language  string    `json:"language"`
+	City      string    `json:"city"`
+	Province  string    `json:"province"`
+	Country   string    `json:"country"`
+	AvatarUrl string    `json:"avatarUrl"`
+	Watermark Watermark `json:"watermark"`
+}
+
+func (wx *Weixin) DecryptUserInfo(params *EncryptedDataReq) (*DecryptedUserInfo, error) {
+	var res = &DecryptedUserInfo{}
+	if err := wx.DecryptData(params, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type WeRunStep struct {
+	Timestamp int64 `json:"timestamp"`
+	Step      int   `json:"step"`
+}
+
+type WeRunData struct {
+	StepInfoList []*WeRunStep `json:"stepInfoList"`
+	Watermark    Watermark    `json:"watermark"`
+}
+
+func (wx *Weixin) DecryptWeRunData(params *EncryptedDataReq) (*WeRunData, error) {
+	var res = &WeRunData{}
+	if err := wx.DecryptData(params, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type ShareInfo struct {
+	OpenGId   string    `json:"openGId"`
+	Watermark Watermark `json:"watermark"`
+}
+
+func (wx *Weixin) DecryptShareInfo(params *EncryptedDataReq) (*ShareInfo, error) {
+	var res = &ShareInfo{}
+	if err := wx.DecryptData(params, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type DecryptedPhoneNumber struct {
+	PhoneNumber     string    `json:"phoneNumber"`
+	PurePhoneNumber string    `json:"purePhoneNumber"`
+	CountryCode     string    `json:"countryCode"`
+	Watermark       Watermark `json:"watermark"`
+}
+
+func (wx *Weixin) DecryptPhoneNumber(params *EncryptedDataReq) (*DecryptedPhoneNumber, error) {
+	var res = &DecryptedPhoneNumber{}
+	if err := wx.DecryptData(params, res); err != nil {
+		return nil, err
+	}
+	if res.PhoneNumber == "" {
+		return nil, errors.New("获取微信绑定手机号失败")
+	}
+	return res, nil
+}
@@ -0,0 +1,48 @@
+package weixin
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	localTime "github.com/go-tron/local-time"
+	"testing"
+)
+
+func encryptData(key []byte, iv []byte, plain []byte) string {
+	n := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(n)}, n)...)
+	block, _ := aes.NewCipher(key)
+	data := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, plain)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func TestDecryptPhoneNumber(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+	plain := fmt.Sprintf(`{"phoneNumber":"+85212345678","purePhoneNumber":"12345678","countryCode":"852","watermark":{"timestamp":%d,"appid":"%s"}}`, localTime.Now().Unix(), maccount.AppId)
+
+	result, err := maccount.DecryptPhoneNumber(&EncryptedDataReq{
+		SessionKey:    base64.StdEncoding.EncodeToString(key),
+		EncryptedData: encryptData(key, iv, []byte(plain)),
+		Iv:            base64.StdEncoding.EncodeToString(iv),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CountryCode != "852" {
+		t.Fatal("result", result)
+	}
+
+	_, err = account.DecryptPhoneNumber(&EncryptedDataReq{
+		SessionKey:    base64.StdEncoding.EncodeToString(key),
+		EncryptedData: encryptData(key, iv, []byte(plain)),
+		Iv:            base64.StdEncoding.EncodeToString(iv),
+	})
+	if err == nil {
+		t.Fatal("watermark appid not verified")
+	}
+	t.Log("result", result)
+}
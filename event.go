@@ -0,0 +1,98 @@
+package weixin
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+)
+
+type MsgType string
+
+const (
+	MsgTypeText  MsgType = "text"
+	MsgTypeImage MsgType = "image"
+	MsgTypeEvent MsgType = "event"
+)
+
+type Event string
+
+const (
+	EventSubscribe                  Event = "subscribe"
+	EventUnsubscribe                Event = "unsubscribe"
+	EventScan                       Event = "SCAN"
+	EventClick                      Event = "CLICK"
+	EventView                       Event = "VIEW"
+	EventMassSendJobFinish          Event = "MASSSENDJOBFINISH"
+	EventTemplateSendJobFinish      Event = "TEMPLATESENDJOBFINISH"
+	EventSubscribeMsgPopup          Event = "subscribe_msg_popup_event"
+	EventSubscribeMsgChange         Event = "subscribe_msg_change_event"
+	EventSubscribeMsgSent           Event = "subscribe_msg_sent_event"
+	EventWxaMediaCheck              Event = "wxa_media_check"
+	EventTradeManageOrderSettlement Event = "trade_manage_order_settlement"
+)
+
+type Message struct {
+	XMLName                 xml.Name              `xml:"xml" json:"-"`
+	ToUserName              string                `xml:"ToUserName" json:"ToUserName"`
+	FromUserName            string                `xml:"FromUserName" json:"FromUserName"`
+	CreateTime              int64                 `xml:"CreateTime" json:"CreateTime"`
+	MsgType                 MsgType               `xml:"MsgType" json:"MsgType"`
+	MsgId                   int64                 `xml:"MsgId" json:"MsgId"`
+	Content                 string                `xml:"Content" json:"Content"`
+	Event                   Event                 `xml:"Event" json:"Event"`
+	EventKey                string                `xml:"EventKey" json:"EventKey"`
+	Ticket                  string                `xml:"Ticket" json:"Ticket"`
+	JobMsgId                int64                 `xml:"MsgID" json:"MsgID"`
+	Status                  string                `xml:"Status" json:"Status"`
+	TotalCount              int                   `xml:"TotalCount" json:"TotalCount"`
+	FilterCount             int                   `xml:"FilterCount" json:"FilterCount"`
+	SentCount               int                   `xml:"SentCount" json:"SentCount"`
+	ErrorCount              int                   `xml:"ErrorCount" json:"ErrorCount"`
+	SubscribeMsgPopupEvent  *SubscribeMsgEvent    `xml:"SubscribeMsgPopupEvent" json:"-"`
+	SubscribeMsgChangeEvent *SubscribeMsgEvent    `xml:"SubscribeMsgChangeEvent" json:"-"`
+	SubscribeMsgSentEvent   *SubscribeMsgEvent    `xml:"SubscribeMsgSentEvent" json:"-"`
+	List                    SubscribeMsgEventList `xml:"-" json:"List"`
+	AppId                   string                `xml:"appid" json:"appid"`
+	TraceId                 string                `xml:"trace_id" json:"trace_id"`
+	SecResult               *SecCheckResult       `xml:"result" json:"result"`
+	SecDetail               []*SecCheckDetail     `xml:"detail" json:"detail"`
+	TransactionId           string                `xml:"transaction_id" json:"transaction_id"`
+	MerchantId              string                `xml:"merchant_id" json:"merchant_id"`
+	SubMerchantId           string                `xml:"sub_merchant_id" json:"sub_merchant_id"`
+	MerchantTradeNo         string                `xml:"merchant_trade_no" json:"merchant_trade_no"`
+	PayTime                 int64                 `xml:"pay_time" json:"pay_time"`
+	ShippedTime             int64                 `xml:"shipped_time" json:"shipped_time"`
+	EstimatedSettlementTime int64                 `xml:"estimated_settlement_time" json:"estimated_settlement_time"`
+	ConfirmReceiveMethod    ConfirmReceiveMethod  `xml:"confirm_receive_method" json:"confirm_receive_method"`
+	ConfirmReceiveTime      int64                 `xml:"confirm_receive_time" json:"confirm_receive_time"`
+	SettlementTime          int64                 `xml:"settlement_time" json:"settlement_time"`
+}
+
+func ParseMessage(body []byte) (*Message, error) {
+	var msg = &Message{}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		if err := json.Unmarshal(body, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+	if err := xml.Unmarshal(body, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (wx *Weixin) HandleMessage(msg *Message) error {
+	if msg.MsgType != MsgTypeEvent {
+		return nil
+	}
+	switch msg.Event {
+	case EventSubscribe, EventScan:
+		return wx.RecordQrCodeScan(msg)
+	case EventMassSendJobFinish:
+		return wx.FinishMassJob(msg)
+	case EventTemplateSendJobFinish:
+		return wx.FinishTemplateSend(msg)
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ require (
 	github.com/go-tron/random v1.0.0
 	github.com/go-tron/redis v1.0.1
 	github.com/google/go-querystring v1.1.0
+	golang.org/x/crypto v0.21.0
 )
 
 require (
@@ -0,0 +1,483 @@
+package weixin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	localTime "github.com/go-tron/local-time"
+	"github.com/go-tron/random"
+	"github.com/go-tron/redis"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MassJobPrefix = "wx-mass-job:"
+	MassMsgPrefix = "wx-mass-msg:"
+	MassJobExpire = time.Hour * 24 * 30
+	MassSendLimit = 10000
+)
+
+type MassMsgType string
+
+const (
+	MassMsgTypeMpNews  MassMsgType = "mpnews"
+	MassMsgTypeText    MassMsgType = "text"
+	MassMsgTypeVoice   MassMsgType = "voice"
+	MassMsgTypeImage   MassMsgType = "image"
+	MassMsgTypeMpVideo MassMsgType = "mpvideo"
+	MassMsgTypeWxCard  MassMsgType = "wxcard"
+)
+
+type MassMedia struct {
+	MediaId string `json:"media_id"`
+}
+
+type MassText struct {
+	Content string `json:"content"`
+}
+
+type MassImage struct {
+	MediaIds           []string `json:"media_ids"`
+	Recommend          string   `json:"recommend,omitempty"`
+	NeedOpenComment    int      `json:"need_open_comment"`
+	OnlyFansCanComment int      `json:"only_fans_can_comment"`
+}
+
+type MassWxCard struct {
+	CardId string `json:"card_id"`
+}
+
+type MassMessage struct {
+	MsgType           MassMsgType `json:"msgtype"`
+	MpNews            *MassMedia  `json:"mpnews,omitempty"`
+	Text              *MassText   `json:"text,omitempty"`
+	Voice             *MassMedia  `json:"voice,omitempty"`
+	Image             *MassImage  `json:"images,omitempty"`
+	MpVideo           *MassMedia  `json:"mpvideo,omitempty"`
+	WxCard            *MassWxCard `json:"wxcard,omitempty"`
+	SendIgnoreReprint int         `json:"send_ignore_reprint"`
+}
+
+type MassSendAllReq struct {
+	IsToAll     bool         `json:"isToAll"`
+	TagId       int          `json:"tagId"`
+	Message     *MassMessage `json:"message"`
+	ClientMsgId string       `json:"clientMsgId"`
+}
+
+type MassSendReq struct {
+	OpenIds     []string     `json:"openIds"`
+	Message     *MassMessage `json:"message"`
+	ClientMsgId string       `json:"clientMsgId"`
+}
+
+type MassSendRes struct {
+	ErrCode   int    `json:"errcode"`
+	ErrMsg    string `json:"errmsg"`
+	MsgId     int64  `json:"msg_id"`
+	MsgDataId int64  `json:"msg_data_id"`
+}
+
+type MassJobStatus string
+
+const (
+	MassJobStatusSendSuccess MassJobStatus = "send success"
+	MassJobStatusSendFail    MassJobStatus = "send fail"
+	// MASSSENDJOBFINISH reports audit failures as err(<code>), e.g. err(10001)
+	MassJobStatusErr MassJobStatus = "err"
+)
+
+var ErrMassJobNotFound = errors.New("mass job not found")
+
+func (s MassJobStatus) Failed() bool {
+	return s == MassJobStatusSendFail || strings.HasPrefix(string(s), string(MassJobStatusErr)+"(")
+}
+
+type MassJobChunk struct {
+	Index       int           `json:"index"`
+	ClientMsgId string        `json:"clientMsgId"`
+	MsgId       int64         `json:"msgId"`
+	MsgDataId   int64         `json:"msgDataId"`
+	Count       int           `json:"count"`
+	Status      MassJobStatus `json:"status"`
+	TotalCount  int           `json:"totalCount"`
+	FilterCount int           `json:"filterCount"`
+	SentCount   int           `json:"sentCount"`
+	ErrorCount  int           `json:"errorCount"`
+	CreatedAt   int64         `json:"createdAt"`
+	FinishedAt  int64         `json:"finishedAt"`
+}
+
+type MassJob struct {
+	JobId  string          `json:"jobId"`
+	Chunks []*MassJobChunk `json:"chunks"`
+}
+
+func (j *MassJob) Finished() bool {
+	for _, chunk := range j.Chunks {
+		if chunk.Status == "" {
+			return false
+		}
+	}
+	return len(j.Chunks) > 0
+}
+
+func (j *MassJob) Failed() bool {
+	for _, chunk := range j.Chunks {
+		if chunk.Status.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *MassJob) TotalCount() (n int) {
+	for _, chunk := range j.Chunks {
+		n += chunk.TotalCount
+	}
+	return n
+}
+
+func (j *MassJob) FilterCount() (n int) {
+	for _, chunk := range j.Chunks {
+		n += chunk.FilterCount
+	}
+	return n
+}
+
+func (j *MassJob) SentCount() (n int) {
+	for _, chunk := range j.Chunks {
+		n += chunk.SentCount
+	}
+	return n
+}
+
+func (j *MassJob) ErrorCount() (n int) {
+	for _, chunk := range j.Chunks {
+		n += chunk.ErrorCount
+	}
+	return n
+}
+
+func (j *MassJob) chunk(clientMsgId string) *MassJobChunk {
+	for _, chunk := range j.Chunks {
+		if chunk.ClientMsgId == clientMsgId {
+			return chunk
+		}
+	}
+	return nil
+}
+
+type MassJobStore interface {
+	SaveMassJobChunk(jobId string, chunk *MassJobChunk) error
+	GetMassJob(jobId string) (*MassJob, error)
+	GetMassJobIdByMsgId(msgId int64) (string, error)
+}
+
+func NewRedisMassJobStore(redis *redis.Redis, appId string) MassJobStore {
+	return &RedisMassJobStore{
+		Redis: redis,
+		AppId: appId,
+	}
+}
+
+type RedisMassJobStore struct {
+	Redis *redis.Redis
+	AppId string
+}
+
+func (s *RedisMassJobStore) SaveMassJobChunk(jobId string, chunk *MassJobChunk) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+	key := MassJobPrefix + s.AppId + ":" + jobId
+	pipe := s.Redis.TxPipeline()
+	pipe.HSet(context.Background(), key, chunk.ClientMsgId, data)
+	pipe.Expire(context.Background(), key, MassJobExpire)
+	if chunk.MsgId != 0 {
+		pipe.Set(context.Background(), MassMsgPrefix+s.AppId+":"+strconv.FormatInt(chunk.MsgId, 10), jobId, MassJobExpire)
+	}
+	_, err = pipe.Exec(context.Background())
+	return err
+}
+
+func (s *RedisMassJobStore) GetMassJob(jobId string) (*MassJob, error) {
+	values, err := s.Redis.HGetAll(context.Background(), MassJobPrefix+s.AppId+":"+jobId).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, ErrMassJobNotFound
+	}
+	job := &MassJob{
+		JobId: jobId,
+	}
+	for _, value := range values {
+		var chunk = &MassJobChunk{}
+		if err := json.Unmarshal([]byte(value), chunk); err != nil {
+			return nil, err
+		}
+		job.Chunks = append(job.Chunks, chunk)
+	}
+	sort.Slice(job.Chunks, func(i, k int) bool {
+		return job.Chunks[i].Index < job.Chunks[k].Index
+	})
+	return job, nil
+}
+
+func (s *RedisMassJobStore) GetMassJobIdByMsgId(msgId int64) (string, error) {
+	jobId, err := s.Redis.Get(context.Background(), MassMsgPrefix+s.AppId+":"+strconv.FormatInt(msgId, 10)).Result()
+	if err == redis.Nil {
+		return "", ErrMassJobNotFound
+	}
+	return jobId, err
+}
+
+func (wx *Weixin) massJobStore() MassJobStore {
+	if wx.MassJobStore != nil {
+		return wx.MassJobStore
+	}
+	return NewRedisMassJobStore(wx.Redis, wx.AppId)
+}
+
+func (wx *Weixin) sendMassChunk(url string, jobId string, chunk *MassJobChunk, job *MassJob, body interface{}) error {
+	if sent := job.chunk(chunk.ClientMsgId); sent != nil && sent.MsgId != 0 {
+		*chunk = *sent
+		return nil
+	}
+
+	var res = &MassSendRes{}
+	if err := wx.post("SendMass", url, body, res); err != nil {
+		return err
+	}
+	chunk.MsgId = res.MsgId
+	chunk.MsgDataId = res.MsgDataId
+	chunk.CreatedAt = localTime.Now().Unix()
+	return wx.massJobStore().SaveMassJobChunk(jobId, chunk)
+}
+
+func (wx *Weixin) existingMassJob(jobId string) (*MassJob, error) {
+	job, err := wx.massJobStore().GetMassJob(jobId)
+	if errors.Is(err, ErrMassJobNotFound) {
+		return &MassJob{JobId: jobId}, nil
+	}
+	return job, err
+}
+
+func (wx *Weixin) MassSendAll(params *MassSendAllReq) (*MassJob, error) {
+	if params.Message == nil {
+		return nil, errors.New("message 必须设置")
+	}
+
+	jobId := params.ClientMsgId
+	if jobId == "" {
+		jobId = random.String(32)
+	}
+	job, err := wx.existingMassJob(jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := &MassJobChunk{
+		ClientMsgId: jobId,
+	}
+	body := &struct {
+		Filter struct {
+			IsToAll bool `json:"is_to_all"`
+			TagId   int  `json:"tag_id,omitempty"`
+		} `json:"filter"`
+		*MassMessage
+		ClientMsgId string `json:"clientmsgid"`
+	}{
+		MassMessage: params.Message,
+		ClientMsgId: chunk.ClientMsgId,
+	}
+	body.Filter.IsToAll = params.IsToAll
+	body.Filter.TagId = params.TagId
+
+	if err := wx.sendMassChunk("https://api.weixin.qq.com/cgi-bin/message/mass/sendall", jobId, chunk, job, body); err != nil {
+		return nil, err
+	}
+	return &MassJob{
+		JobId:  jobId,
+		Chunks: []*MassJobChunk{chunk},
+	}, nil
+}
+
+func chunkOpenIds(openIds []string, size int) [][]string {
+	var chunks [][]string
+	for len(openIds) > 0 {
+		n := size
+		if len(openIds) < n {
+			n = len(openIds)
+		}
+		// message/mass/send rejects a touser list with a single openid
+		if rest := len(openIds) - n; rest == 1 && n > 2 {
+			n--
+		}
+		chunks = append(chunks, openIds[:n])
+		openIds = openIds[n:]
+	}
+	return chunks
+}
+
+func (wx *Weixin) MassSend(params *MassSendReq) (*MassJob, error) {
+	if params.Message == nil {
+		return nil, errors.New("message 必须设置")
+	}
+	if len(params.OpenIds) < 2 {
+		return nil, errors.New("openIds 至少需要2个")
+	}
+
+	jobId := params.ClientMsgId
+	if jobId == "" {
+		jobId = random.String(32)
+	}
+	job, err := wx.existingMassJob(jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	var result = &MassJob{
+		JobId: jobId,
+	}
+	for i, openIds := range chunkOpenIds(params.OpenIds, MassSendLimit) {
+		chunk := &MassJobChunk{
+			Index:       i,
+			ClientMsgId: fmt.Sprintf("%s-%d", jobId, i),
+			Count:       len(openIds),
+		}
+		body := &struct {
+			ToUser []string `json:"touser"`
+			*MassMessage
+			ClientMsgId string `json:"clientmsgid"`
+		}{
+			ToUser:      openIds,
+			MassMessage: params.Message,
+			ClientMsgId: chunk.ClientMsgId,
+		}
+		if err := wx.sendMassChunk("https://api.weixin.qq.com/cgi-bin/message/mass/send", jobId, chunk, job, body); err != nil {
+			return result, err
+		}
+		result.Chunks = append(result.Chunks, chunk)
+	}
+	return result, nil
+}
+
+type MassPreviewReq struct {
+	OpenId  string       `json:"openId"`
+	WxName  string       `json:"wxName"`
+	Message *MassMessage `json:"message"`
+}
+
+func (wx *Weixin) MassPreview(params *MassPreviewReq) error {
+	if params.Message == nil {
+		return errors.New("message 必须设置")
+	}
+	body := &struct {
+		ToUser   string `json:"touser,omitempty"`
+		ToWxName string `json:"towxname,omitempty"`
+		*MassMessage
+	}{
+		ToUser:      params.OpenId,
+		ToWxName:    params.WxName,
+		MassMessage: params.Message,
+	}
+	return wx.post("MassPreview", "https://api.weixin.qq.com/cgi-bin/message/mass/preview", body, nil)
+}
+
+type MassDeleteReq struct {
+	MsgId      int64  `json:"msg_id"`
+	ArticleIdx int    `json:"article_idx,omitempty"`
+	Url        string `json:"url,omitempty"`
+}
+
+func (wx *Weixin) MassDelete(params *MassDeleteReq) error {
+	return wx.post("MassDelete", "https://api.weixin.qq.com/cgi-bin/message/mass/delete", params, nil)
+}
+
+type MassGetRes struct {
+	ErrCode   int    `json:"errcode"`
+	ErrMsg    string `json:"errmsg"`
+	MsgId     int64  `json:"msg_id"`
+	MsgStatus string `json:"msg_status"`
+}
+
+func (wx *Weixin) MassGet(msgId int64) (*MassGetRes, error) {
+	var res = &MassGetRes{}
+	if err := wx.post("MassGet", "https://api.weixin.qq.com/cgi-bin/message/mass/get", map[string]int64{
+		"msg_id": msgId,
+	}, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type MassSpeedRes struct {
+	ErrCode   int    `json:"errcode"`
+	ErrMsg    string `json:"errmsg"`
+	Speed     int    `json:"speed"`
+	RealSpeed int    `json:"realspeed"`
+}
+
+func (wx *Weixin) MassSpeedGet() (*MassSpeedRes, error) {
+	var res = &MassSpeedRes{}
+	if err := wx.post("MassSpeedGet", "https://api.weixin.qq.com/cgi-bin/message/mass/speed/get", map[string]interface{}{}, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (wx *Weixin) MassSpeedSet(speed int) error {
+	return wx.post("MassSpeedSet", "https://api.weixin.qq.com/cgi-bin/message/mass/speed/set", map[string]int{
+		"speed": speed,
+	}, nil)
+}
+
+func (wx *Weixin) GetMassJob(jobId string) (*MassJob, error) {
+	return wx.massJobStore().GetMassJob(jobId)
+}
+
+func (wx *Weixin) FinishMassJob(msg *Message) error {
+	store := wx.massJobStore()
+	jobId, err := store.GetMassJobIdByMsgId(msg.JobMsgId)
+	if errors.Is(err, ErrMassJobNotFound) {
+		wx.Logger.Debug("FinishMassJob", wx.Logger.Field("error", err), wx.Logger.Field("msgId", msg.JobMsgId), wx.Logger.Field("appId", wx.AppId))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	job, err := store.GetMassJob(jobId)
+	if errors.Is(err, ErrMassJobNotFound) {
+		wx.Logger.Debug("FinishMassJob", wx.Logger.Field("error", err), wx.Logger.Field("jobId", jobId), wx.Logger.Field("msgId", msg.JobMsgId), wx.Logger.Field("appId", wx.AppId))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, chunk := range job.Chunks {
+		if chunk.MsgId != msg.JobMsgId {
+			continue
+		}
+		chunk.Status = MassJobStatus(msg.Status)
+		chunk.TotalCount = msg.TotalCount
+		chunk.FilterCount = msg.FilterCount
+		chunk.SentCount = msg.SentCount
+		chunk.ErrorCount = msg.ErrorCount
+		chunk.FinishedAt = msg.CreateTime
+		wx.Logger.Info("FinishMassJob",
+			wx.Logger.Field("jobId", jobId),
+			wx.Logger.Field("msgId", msg.JobMsgId),
+			wx.Logger.Field("status", msg.Status),
+			wx.Logger.Field("appId", wx.AppId))
+		return store.SaveMassJobChunk(jobId, chunk)
+	}
+	wx.Logger.Debug("FinishMassJob", wx.Logger.Field("error", "mass job chunk not found"), wx.Logger.Field("jobId", jobId), wx.Logger.Field("msgId", msg.JobMsgId), wx.Logger.Field("appId", wx.AppId))
+	return nil
+}
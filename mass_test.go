@@ -0,0 +1,110 @@
+package weixin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChunkOpenIds(t *testing.T) {
+	openIds := make([]string, 20001)
+	chunks := chunkOpenIds(openIds, MassSendLimit)
+	if len(chunks) != 3 {
+		t.Fatal("chunks", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if len(chunk) < 2 || len(chunk) > MassSendLimit {
+			t.Fatal("chunk size", len(chunk))
+		}
+	}
+	t.Log("result", len(chunks[0]), len(chunks[1]), len(chunks[2]))
+}
+
+func TestMassSend(t *testing.T) {
+	job, err := account.MassSend(&MassSendReq{
+		OpenIds: []string{"oasi95rPit953LHRYfaifGnTuqgs", "opZow6IEeQkp1y03HWfjZW0njPUE"},
+		Message: &MassMessage{
+			MsgType: MassMsgTypeText,
+			Text: &MassText{
+				Content: "content",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", job)
+}
+
+func TestFinishMassJob(t *testing.T) {
+	msg, err := ParseMessage([]byte(`<xml><ToUserName><![CDATA[gh_4d00ed8d6399]]></ToUserName><FromUserName><![CDATA[oV5CrjpxgaGXNHIQigzNlgLTnwic]]></FromUserName><CreateTime>1481013459</CreateTime><MsgType><![CDATA[event]]></MsgType><Event><![CDATA[MASSSENDJOBFINISH]]></Event><MsgID>1000001625</MsgID><Status><![CDATA[send success]]></Status><TotalCount>2</TotalCount><FilterCount>2</FilterCount><SentCount>2</SentCount><ErrorCount>0</ErrorCount></xml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewRedisMassJobStore(account.Redis, account.AppId)
+	if err := store.SaveMassJobChunk("job", &MassJobChunk{ClientMsgId: "job-0", MsgId: 1000001625, Count: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := account.HandleMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	job, err := account.GetMassJob("job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.Finished() || job.SentCount() != 2 {
+		t.Fatal("job", job.Chunks[0])
+	}
+	t.Log("result", job.Chunks[0])
+}
+
+func TestMassJobStatusFailed(t *testing.T) {
+	for status, failed := range map[MassJobStatus]bool{
+		MassJobStatusSendSuccess: false,
+		MassJobStatusSendFail:    true,
+		"err(10001)":             true,
+		"err(20013)":             true,
+		"":                       false,
+	} {
+		if status.Failed() != failed {
+			t.Fatal("status", status)
+		}
+	}
+}
+
+func TestFinishMassJobUnknown(t *testing.T) {
+	msg, err := ParseMessage([]byte(`<xml><ToUserName><![CDATA[gh_4d00ed8d6399]]></ToUserName><FromUserName><![CDATA[oV5CrjpxgaGXNHIQigzNlgLTnwic]]></FromUserName><CreateTime>1481013459</CreateTime><MsgType><![CDATA[event]]></MsgType><Event><![CDATA[MASSSENDJOBFINISH]]></Event><MsgID>1000009999</MsgID><Status><![CDATA[err(10001)]]></Status><TotalCount>2</TotalCount><FilterCount>0</FilterCount><SentCount>0</SentCount><ErrorCount>0</ErrorCount></xml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := account.HandleMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type testMassJobStore struct {
+	MassJobStore
+	err error
+}
+
+func (s *testMassJobStore) GetMassJob(jobId string) (*MassJob, error) {
+	return nil, s.err
+}
+
+func TestMassSendStoreError(t *testing.T) {
+	config := *account.Config
+	config.MassJobStore = &testMassJobStore{err: errors.New("store unavailable")}
+	wx := &Weixin{Config: &config}
+	_, err := wx.MassSend(&MassSendReq{
+		ClientMsgId: "job",
+		OpenIds:     []string{"oasi95rPit953LHRYfaifGnTuqgs", "opZow6IEeQkp1y03HWfjZW0njPUE"},
+		Message: &MassMessage{
+			MsgType: MassMsgTypeText,
+			Text: &MassText{
+				Content: "content",
+			},
+		},
+	})
+	if err == nil || err.Error() != "store unavailable" {
+		t.Fatal("err", err)
+	}
+}
@@ -1,24 +1,26 @@
 package weixin
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	localTime "github.com/go-tron/local-time"
+	"github.com/go-tron/redis"
 )
 
 type GetUserPhoneNumberRes struct {
-	ErrCode   int    `json:"errcode"`
-	ErrMsg    string `json:"errmsg"`
-	PhoneInfo struct {
-		PhoneNumber     string `json:"phoneNumber"`
-		PurePhoneNumber string `json:"purePhoneNumber"`
-		CountryCode     string `json:"countryCode"`
-	} `json:"phone_info"`
+	ErrCode   int             `json:"errcode"`
+	ErrMsg    string          `json:"errmsg"`
+	PhoneInfo UserPhoneNumber `json:"phone_info"`
 }
 
 type UserPhoneNumber struct {
-	PhoneNumber string `json:"phoneNumber"`
+	PhoneNumber     string    `json:"phoneNumber"`
+	PurePhoneNumber string    `json:"purePhoneNumber"`
+	CountryCode     string    `json:"countryCode"`
+	Watermark       Watermark `json:"watermark"`
 }
 
 func (wx *Weixin) GetUserPhoneNumber(code string) (*UserPhoneNumber, error) {
@@ -37,9 +39,12 @@ func (wx *Weixin) GetUserPhoneNumber(code string) (*UserPhoneNumber, error) {
 	if err != nil {
 		return nil, err
 	}
+	return decodeUserPhoneNumber(wx.AppId, resp.Body())
+}
 
+func decodeUserPhoneNumber(appId string, body []byte) (*UserPhoneNumber, error) {
 	var res = &GetUserPhoneNumberRes{}
-	if err := json.Unmarshal(resp.Body(), res); err != nil {
+	if err := json.Unmarshal(body, res); err != nil {
 		return nil, err
 	}
 	if res.ErrCode != 0 {
@@ -53,7 +58,138 @@ func (wx *Weixin) GetUserPhoneNumber(code string) (*UserPhoneNumber, error) {
 	if res.PhoneInfo.PhoneNumber == "" {
 		return nil, errors.New("获取微信绑定手机号失败")
 	}
-	return &UserPhoneNumber{
-		PhoneNumber: res.PhoneInfo.PhoneNumber,
-	}, nil
+	if err := res.PhoneInfo.Watermark.Verify(appId); err != nil {
+		return nil, err
+	}
+	return &res.PhoneInfo, nil
+}
+
+const PhoneBindingPrefix = "wx-phone-binding:"
+
+type PhoneBinding struct {
+	OpenId          string `json:"openId"`
+	PhoneNumber     string `json:"phoneNumber"`
+	PurePhoneNumber string `json:"purePhoneNumber"`
+	CountryCode     string `json:"countryCode"`
+	BoundAt         int64  `json:"boundAt"`
+}
+
+type PhoneBindingStore interface {
+	SavePhoneBinding(binding *PhoneBinding) error
+	GetPhoneBinding(openId string) (*PhoneBinding, error)
+}
+
+func NewRedisPhoneBindingStore(redis *redis.Redis, appId string) PhoneBindingStore {
+	return &RedisPhoneBindingStore{
+		Redis: redis,
+		AppId: appId,
+	}
+}
+
+type RedisPhoneBindingStore struct {
+	Redis *redis.Redis
+	AppId string
+}
+
+func (s *RedisPhoneBindingStore) SavePhoneBinding(binding *PhoneBinding) error {
+	data, err := json.Marshal(binding)
+	if err != nil {
+		return err
+	}
+	return s.Redis.Set(context.Background(), PhoneBindingPrefix+s.AppId+":"+binding.OpenId, data, 0).Err()
+}
+
+func (s *RedisPhoneBindingStore) GetPhoneBinding(openId string) (*PhoneBinding, error) {
+	data, err := s.Redis.Get(context.Background(), PhoneBindingPrefix+s.AppId+":"+openId).Result()
+	if err == redis.Nil {
+		return nil, errors.New("phone binding not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var binding = &PhoneBinding{}
+	if err := json.Unmarshal([]byte(data), binding); err != nil {
+		return nil, err
+	}
+	return binding, nil
+}
+
+func (wx *Weixin) phoneBindingStore() PhoneBindingStore {
+	if wx.PhoneBindingStore != nil {
+		return wx.PhoneBindingStore
+	}
+	return NewRedisPhoneBindingStore(wx.Redis, wx.AppId)
+}
+
+func (wx *Weixin) BindUserPhoneNumber(openId string, code string) (*UserPhoneNumber, error) {
+	if openId == "" {
+		return nil, errors.New("openId 必须设置")
+	}
+	phone, err := wx.GetUserPhoneNumber(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := wx.bindPhoneNumber(openId, phone); err != nil {
+		return nil, err
+	}
+	return phone, nil
+}
+
+func (wx *Weixin) bindPhoneNumber(openId string, phone *UserPhoneNumber) error {
+	return wx.phoneBindingStore().SavePhoneBinding(&PhoneBinding{
+		OpenId:          openId,
+		PhoneNumber:     phone.PhoneNumber,
+		PurePhoneNumber: phone.PurePhoneNumber,
+		CountryCode:     phone.CountryCode,
+		BoundAt:         localTime.Now().Unix(),
+	})
+}
+
+func (wx *Weixin) GetPhoneBinding(openId string) (*PhoneBinding, error) {
+	return wx.phoneBindingStore().GetPhoneBinding(openId)
+}
+
+type MiniProgramState string
+
+const (
+	MiniProgramStateDeveloper MiniProgramState = "developer"
+	MiniProgramStateTrial     MiniProgramState = "trial"
+	MiniProgramStateFormal    MiniProgramState = "formal"
+)
+
+type MiniProgramLang string
+
+const (
+	MiniProgramLangZhCN MiniProgramLang = "zh_CN"
+	MiniProgramLangEnUS MiniProgramLang = "en_US"
+	MiniProgramLangZhHK MiniProgramLang = "zh_HK"
+	MiniProgramLangZhTW MiniProgramLang = "zh_TW"
+)
+
+type MiniProgramSubscribeReq struct {
+	OpenId           string           `json:"openId"`
+	TemplateId       string           `json:"templateId"`
+	Page             string           `json:"page"`
+	MiniProgramState MiniProgramState `json:"miniProgramState"`
+	Lang             MiniProgramLang  `json:"lang"`
+	Data             SubscribeData    `json:"data"`
+}
+
+func (wx *Weixin) SendMiniProgramSubscribe(params *MiniProgramSubscribeReq) error {
+	body := &struct {
+		ToUser           string           `json:"touser"`
+		TemplateId       string           `json:"template_id"`
+		Page             string           `json:"page,omitempty"`
+		MiniProgramState MiniProgramState `json:"miniprogram_state,omitempty"`
+		Lang             MiniProgramLang  `json:"lang,omitempty"`
+		Data             SubscribeData    `json:"data"`
+	}{
+		ToUser:           params.OpenId,
+		TemplateId:       params.TemplateId,
+		Page:             params.Page,
+		MiniProgramState: params.MiniProgramState,
+		Lang:             params.Lang,
+		Data:             params.Data,
+	}
+	return wx.post("SendMiniProgramSubscribe", "https://api.weixin.qq.com/cgi-bin/message/subscribe/send", body, nil)
 }
@@ -1,9 +1,13 @@
 package weixin
 
 import (
+	"errors"
+	"fmt"
+	localTime "github.com/go-tron/local-time"
 	"github.com/go-tron/logger"
 	"github.com/go-tron/redis"
 	"testing"
+	"time"
 )
 
 var maccount = Weixin{
@@ -29,3 +33,203 @@ func TestGetUserPhoneNumber(t *testing.T) {
 	}
 	t.Log("result", result)
 }
+
+func TestWatermarkVerify(t *testing.T) {
+	now := localTime.Now().Unix()
+	if err := (&Watermark{AppId: maccount.AppId, Timestamp: now}).Verify(maccount.AppId); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Watermark{AppId: "wx6c8124f1fbafb1f3", Timestamp: now}).Verify(maccount.AppId); err == nil {
+		t.Fatal("watermark of another appid accepted")
+	}
+	if err := (&Watermark{AppId: maccount.AppId, Timestamp: now - int64(WatermarkExpire/time.Second) - 60}).Verify(maccount.AppId); err == nil {
+		t.Fatal("expired watermark accepted")
+	}
+	if err := (&Watermark{AppId: maccount.AppId}).Verify(maccount.AppId); err == nil {
+		t.Fatal("watermark without timestamp accepted")
+	}
+}
+
+func TestDecodeUserPhoneNumber(t *testing.T) {
+	body := func(appId string, timestamp int64) []byte {
+		return []byte(fmt.Sprintf(`{"errcode":0,"errmsg":"ok","phone_info":{"phoneNumber":"+86 13800138000","purePhoneNumber":"13800138000","countryCode":"86","watermark":{"appid":"%s","timestamp":%d}}}`, appId, timestamp))
+	}
+	now := localTime.Now().Unix()
+	phone, err := decodeUserPhoneNumber(maccount.AppId, body(maccount.AppId, now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phone.PurePhoneNumber != "13800138000" {
+		t.Fatal("phone", phone)
+	}
+	if _, err := decodeUserPhoneNumber(maccount.AppId, body("wx6c8124f1fbafb1f3", now)); err == nil {
+		t.Fatal("watermark of another appid accepted")
+	}
+	if _, err := decodeUserPhoneNumber(maccount.AppId, body(maccount.AppId, now-int64(WatermarkExpire/time.Second)-60)); err == nil {
+		t.Fatal("expired watermark accepted")
+	}
+}
+
+type testPhoneBindingStore struct {
+	bindings map[string]*PhoneBinding
+}
+
+func (s *testPhoneBindingStore) SavePhoneBinding(binding *PhoneBinding) error {
+	s.bindings[binding.OpenId] = binding
+	return nil
+}
+
+func (s *testPhoneBindingStore) GetPhoneBinding(openId string) (*PhoneBinding, error) {
+	binding, ok := s.bindings[openId]
+	if !ok {
+		return nil, errors.New("phone binding not found")
+	}
+	return binding, nil
+}
+
+func TestPhoneBinding(t *testing.T) {
+	store := &testPhoneBindingStore{bindings: make(map[string]*PhoneBinding)}
+	config := *maccount.Config
+	config.PhoneBindingStore = store
+	wx := &Weixin{Config: &config}
+
+	if _, err := wx.BindUserPhoneNumber("", "code"); err == nil {
+		t.Fatal("empty openId accepted")
+	}
+	if err := wx.bindPhoneNumber("oasi95rPit953LHRYfaifGnTuqgs", &UserPhoneNumber{
+		PhoneNumber:     "+86 13800138000",
+		PurePhoneNumber: "13800138000",
+		CountryCode:     "86",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	binding, err := wx.GetPhoneBinding("oasi95rPit953LHRYfaifGnTuqgs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binding.PurePhoneNumber != "13800138000" || binding.CountryCode != "86" || binding.BoundAt == 0 {
+		t.Fatal("binding", binding)
+	}
+	if _, err := wx.GetPhoneBinding("opZow6IEeQkp1y03HWfjZW0njPUE"); err == nil {
+		t.Fatal("unknown openId bound")
+	}
+}
+
+func TestSendMiniProgramSubscribe(t *testing.T) {
+	err := maccount.SendMiniProgramSubscribe(&MiniProgramSubscribeReq{
+		OpenId:           "oasi95rPit953LHRYfaifGnTuqgs",
+		TemplateId:       "ngqIpbwh8bUfcSsECmogfXcV14J0tQlEpBO27izEYtY",
+		Page:             "pages/order/detail?id=1",
+		MiniProgramState: MiniProgramStateTrial,
+		Lang:             MiniProgramLangZhCN,
+		Data: NewSubscribeData().
+			Set("character_string1", "202301010001").
+			Set("phrase2", "已发货"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", "success")
+}
+
+func TestSubscribeMsgEvents(t *testing.T) {
+	msg, err := ParseMessage([]byte(`<xml><ToUserName><![CDATA[gh_123456789abc]]></ToUserName><FromUserName><![CDATA[otFpruAK8D-E6EfStSYonYSBZ8_4]]></FromUserName><CreateTime>1610969440</CreateTime><MsgType><![CDATA[event]]></MsgType><Event><![CDATA[subscribe_msg_popup_event]]></Event><SubscribeMsgPopupEvent><List><TemplateId><![CDATA[VRR0UEO9VJOLs0MHlU0OilqX6MVFDwH3_3gz3Oc0NIc]]></TemplateId><SubscribeStatusString><![CDATA[accept]]></SubscribeStatusString><PopupScene>2</PopupScene></List><List><TemplateId><![CDATA[9nLIlbOQZC5Y89AZteFEux3WCXRRRG5Wfzkpssu4bLI]]></TemplateId><SubscribeStatusString><![CDATA[reject]]></SubscribeStatusString><PopupScene>2</PopupScene></List></SubscribeMsgPopupEvent></xml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events := msg.SubscribeMsgEvents(); len(events) != 2 || events[1].SubscribeStatusString != SubscribeStatusReject {
+		t.Fatal("events", events)
+	}
+
+	msg, err = ParseMessage([]byte(`{"ToUserName":"gh_123456789abc","FromUserName":"o7esq5OI1Uej6Xixw1lA2H7XDVbc","CreateTime":1620973045,"MsgType":"event","Event":"subscribe_msg_change_event","List":{"TemplateId":"BEwX0BOT3MqK3Uc5oTU3CGBqzjpndk2jzUf7VfExd8","SubscribeStatusString":"reject"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events := msg.SubscribeMsgEvents(); len(events) != 1 || events[0].SubscribeStatusString != SubscribeStatusReject {
+		t.Fatal("events", events)
+	}
+	t.Log("result", msg.SubscribeMsgEvents()[0])
+}
+
+func TestCode2Session(t *testing.T) {
+	result, err := maccount.Code2Session("0a3Fqq000uXXdP1Kfh200sTxSL3Fqq0l")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", result)
+
+	if err := maccount.CheckSession(result.OpenId); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetWxaCodeUnlimit(t *testing.T) {
+	maccount.WxaCodeCache = NewRedisBlobStore(maccount.Redis, WxaCodePrefix, WxaCodeExpire)
+	result, err := maccount.GetWxaCodeUnlimit(&WxaCodeUnlimitReq{
+		Scene:      "id=1",
+		Page:       "pages/index/index",
+		Width:      430,
+		EnvVersion: EnvVersionTrial,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", len(result))
+}
+
+func TestGenerateUrlLink(t *testing.T) {
+	result, err := maccount.GenerateUrlLink(&GenerateUrlLinkReq{
+		Path:           "pages/index/index",
+		Query:          "from=sms",
+		EnvVersion:     EnvVersionRelease,
+		IsExpire:       true,
+		ExpireType:     ExpireTypeInterval,
+		ExpireInterval: 30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", result)
+
+	info, err := maccount.QueryUrlLink(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", info)
+}
+
+func TestUploadShippingInfo(t *testing.T) {
+	err := maccount.UploadShippingInfo(&UploadShippingInfoReq{
+		OrderKey: &ShippingOrderKey{
+			OrderNumberType: OrderNumberTypeTransactionId,
+			TransactionId:   "4200001234202301011234567890",
+		},
+		LogisticsType:  LogisticsTypeVirtual,
+		DeliveryMode:   DeliveryModeUnified,
+		IsAllDelivered: true,
+		ShippingList: []*Shipping{
+			{
+				ItemDesc: "item",
+			},
+		},
+		Payer: &ShippingPayer{
+			OpenId: "oasi95rPit953LHRYfaifGnTuqgs",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", "success")
+}
+
+func TestOrderSettlement(t *testing.T) {
+	msg, err := ParseMessage([]byte(`<xml><ToUserName><![CDATA[gh_e9e7df9bd7e5]]></ToUserName><FromUserName><![CDATA[o8ZJB5Pb3b8uFxhCs1e4DiDRi4aU]]></FromUserName><CreateTime>1677234958</CreateTime><MsgType><![CDATA[event]]></MsgType><Event><![CDATA[trade_manage_order_settlement]]></Event><transaction_id><![CDATA[4200001740202302215786286356]]></transaction_id><merchant_id><![CDATA[1230000109]]></merchant_id><sub_merchant_id><![CDATA[]]></sub_merchant_id><merchant_trade_no><![CDATA[1219]]></merchant_trade_no><pay_time>1676971637</pay_time><shipped_time>1676974000</shipped_time><estimated_settlement_time>1677579400</estimated_settlement_time><confirm_receive_method>1</confirm_receive_method><confirm_receive_time>1677234958</confirm_receive_time><settlement_time>1677234958</settlement_time></xml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := msg.OrderSettlement()
+	if result == nil || result.MerchantTradeNo != "1219" || result.ConfirmReceiveMethod != ConfirmReceiveMethodUser {
+		t.Fatal("result", result)
+	}
+	t.Log("result", result)
+}
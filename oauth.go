@@ -23,11 +23,12 @@ type OAuthCodeReq struct {
 }
 
 type OAuthCodeQuery struct {
-	AppId        string `url:"appid"`
-	RedirectUri  string `url:"redirect_uri"`
-	ResponseType string `url:"response_type"`
-	Scope        string `url:"scope"`
-	State        string `url:"state"`
+	AppId          string `url:"appid"`
+	RedirectUri    string `url:"redirect_uri"`
+	ResponseType   string `url:"response_type"`
+	Scope          string `url:"scope"`
+	State          string `url:"state"`
+	ComponentAppId string `url:"component_appid,omitempty"`
 }
 
 func (wx *Weixin) GetOAuthCode(params *OAuthCodeReq) (string, error) {
@@ -39,6 +40,10 @@ func (wx *Weixin) GetOAuthCode(params *OAuthCodeReq) (string, error) {
 		State:        params.State,
 	}
 
+	if source, ok := wx.componentTokenSource(); ok {
+		req.ComponentAppId = source.ComponentAppId()
+	}
+
 	if wx.OAuthRedirectUri != "" {
 		req.RedirectUri = wx.OAuthRedirectUri + req.RedirectUri
 	}
@@ -59,15 +64,38 @@ type OAuthAccessTokenRes struct {
 	OpenId       string `json:"openid"`
 }
 
+func (wx *Weixin) snsParams(params map[string]string) (map[string]string, error) {
+	params["appid"] = wx.AppId
+	source, ok := wx.componentTokenSource()
+	if !ok {
+		params["secret"] = wx.Secret
+		return params, nil
+	}
+	accessToken, err := source.ComponentAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	params["component_appid"] = source.ComponentAppId()
+	params["component_access_token"] = accessToken
+	return params, nil
+}
+
 func (wx *Weixin) GetOAuthAccessToken(code string) (*OAuthAccessTokenRes, error) {
+	url := "https://api.weixin.qq.com/sns/oauth2/access_token"
+	if _, ok := wx.componentTokenSource(); ok {
+		url = "https://api.weixin.qq.com/sns/oauth2/component/access_token"
+	}
+	params, err := wx.snsParams(map[string]string{
+		"code":       code,
+		"grant_type": "authorization_code",
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	resp, err := resty.New().R().
-		SetQueryParams(map[string]string{
-			"appid":      wx.AppId,
-			"secret":     wx.Secret,
-			"code":       code,
-			"grant_type": "authorization_code",
-		}).
-		Get("https://api.weixin.qq.com/sns/oauth2/access_token")
+		SetQueryParams(params).
+		Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -78,50 +78,75 @@ func (wx *Weixin) GetUserInfo(openId string) (*GetUserInfoRes, error) {
 	return res, nil
 }
 
-type TemplateReq struct {
-	OpenId     string                 `json:"openId"`
-	TemplateId string                 `json:"templateId"`
-	Url        string                 `json:"url"`
-	Data       map[string]interface{} `json:"data"`
+type TemplateKeyword struct {
+	Value string `json:"value"`
+	Color string `json:"color,omitempty"`
 }
 
-type TemplateRes struct {
-	ErrCode int    `json:"errcode"`
-	ErrMsg  string `json:"errmsg"`
-	MsgId   string `json:"msgid"`
+type TemplateData map[string]*TemplateKeyword
+
+func NewTemplateData() TemplateData {
+	return TemplateData{}
 }
 
-func (wx *Weixin) SendTemplate(template *TemplateReq) error {
-	accessToken, err := wx.GetAccessToken()
-	if err != nil {
-		return err
+func (d TemplateData) Set(key string, value string) TemplateData {
+	d[key] = &TemplateKeyword{
+		Value: value,
 	}
+	return d
+}
 
-	resp, err := resty.New().R().
-		SetBody(map[string]interface{}{
-			"touser":      template.OpenId,
-			"template_id": template.TemplateId,
-			"url":         template.Url,
-			"data":        template.Data,
-		}).
-		Post("https://api.weixin.qq.com/cgi-bin/message/template/send?access_token=" + accessToken.AccessToken)
-	if err != nil {
-		return err
+func (d TemplateData) SetWithColor(key string, value string, color string) TemplateData {
+	d[key] = &TemplateKeyword{
+		Value: value,
+		Color: color,
 	}
+	return d
+}
 
-	var res = &OAuthAccessTokenRes{}
-	if err := json.Unmarshal(resp.Body(), res); err != nil {
-		return err
+type TemplateMiniProgram struct {
+	AppId    string `json:"appid"`
+	PagePath string `json:"pagepath"`
+}
+
+type TemplateReq struct {
+	OpenId      string               `json:"openId"`
+	TemplateId  string               `json:"templateId"`
+	Url         string               `json:"url"`
+	MiniProgram *TemplateMiniProgram `json:"miniProgram"`
+	ClientMsgId string               `json:"clientMsgId"`
+	Data        TemplateData         `json:"data"`
+}
+
+type TemplateRes struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	MsgId   int64  `json:"msgid"`
+}
+
+func (wx *Weixin) SendTemplate(template *TemplateReq) (int64, error) {
+	body := &struct {
+		ToUser      string               `json:"touser"`
+		TemplateId  string               `json:"template_id"`
+		Url         string               `json:"url,omitempty"`
+		MiniProgram *TemplateMiniProgram `json:"miniprogram,omitempty"`
+		ClientMsgId string               `json:"client_msg_id,omitempty"`
+		Data        TemplateData         `json:"data"`
+	}{
+		ToUser:      template.OpenId,
+		TemplateId:  template.TemplateId,
+		Url:         template.Url,
+		MiniProgram: template.MiniProgram,
+		ClientMsgId: template.ClientMsgId,
+		Data:        template.Data,
 	}
 
-	if res.ErrCode != 0 {
-		if res.ErrMsg != "" {
-			return errors.New(fmt.Sprintf("(%d)%s", res.ErrCode, res.ErrMsg))
-		} else {
-			return errors.New("SendTemplate")
-		}
+	var res = &TemplateRes{}
+	if err := wx.post("SendTemplate", "https://api.weixin.qq.com/cgi-bin/message/template/send", body, res); err != nil {
+		return 0, err
 	}
-	return nil
+	wx.recordTemplateSend(template, res.MsgId)
+	return res.MsgId, nil
 }
 
 type BatchGetMaterialReq struct {
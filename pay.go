@@ -0,0 +1,18 @@
+package weixin
+
+import (
+	"github.com/go-tron/weixin/pay"
+)
+
+func (wx *Weixin) NewPay(c *pay.Config) *pay.Client {
+	if c.AppId == "" {
+		c.AppId = wx.AppId
+	}
+	if c.Logger == nil {
+		c.Logger = wx.Logger
+	}
+	if c.Redis == nil {
+		c.Redis = wx.Redis
+	}
+	return pay.New(c)
+}
@@ -0,0 +1,450 @@
+package pay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/google/go-querystring/query"
+	"hash"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type BillType string
+
+const (
+	BillTypeAll     BillType = "ALL"
+	BillTypeSuccess BillType = "SUCCESS"
+	BillTypeRefund  BillType = "REFUND"
+)
+
+type AccountType string
+
+const (
+	AccountTypeBasic     AccountType = "BASIC"
+	AccountTypeOperation AccountType = "OPERATION"
+	AccountTypeFees      AccountType = "FEES"
+)
+
+type TradeBillReq struct {
+	BillDate string   `url:"bill_date"`
+	BillType BillType `url:"bill_type,omitempty"`
+	TarType  string   `url:"tar_type,omitempty"`
+}
+
+type FundFlowBillReq struct {
+	BillDate    string      `url:"bill_date"`
+	AccountType AccountType `url:"account_type,omitempty"`
+	TarType     string      `url:"tar_type,omitempty"`
+}
+
+type BillRes struct {
+	HashType    string `json:"hash_type"`
+	HashValue   string `json:"hash_value"`
+	DownloadUrl string `json:"download_url"`
+}
+
+func (c *Client) bill(path string, params interface{}) (*BillRes, error) {
+	v, err := query.Values(params)
+	if err != nil {
+		return nil, err
+	}
+	var res = &BillRes{}
+	if _, err := c.request("GET", path+"?"+v.Encode(), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) TradeBill(params *TradeBillReq) (*BillRes, error) {
+	return c.bill("/v3/bill/tradebill", params)
+}
+
+func (c *Client) FundFlowBill(params *FundFlowBillReq) (*BillRes, error) {
+	return c.bill("/v3/bill/fundflowbill", params)
+}
+
+func NewBillReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+type billReader struct {
+	io.Reader
+	body      io.Closer
+	hash      hash.Hash
+	hashValue string
+}
+
+func (r *billReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF && !strings.EqualFold(hex.EncodeToString(r.hash.Sum(nil)), r.hashValue) {
+		return n, errors.New("bill hash invalid")
+	}
+	return n, err
+}
+
+func (r *billReader) Close() error {
+	return r.body.Close()
+}
+
+// DownloadBill streams the bill, the SHA1 is checked when the reader hits EOF
+func (c *Client) DownloadBill(bill *BillRes) (io.ReadCloser, error) {
+	if bill.HashType != "" && bill.HashType != "SHA1" {
+		return nil, errors.New("hash type not supported:" + bill.HashType)
+	}
+	u, err := url.Parse(bill.DownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+	authorization, err := c.authorization("GET", u.RequestURI(), "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := resty.New().R().
+		SetDoNotParseResponse(true).
+		SetHeader("Authorization", authorization).
+		Get(bill.DownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+	body := resp.RawBody()
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		defer body.Close()
+		data, _ := io.ReadAll(io.LimitReader(body, 1<<16))
+		return nil, decodeErrorBody(resp.StatusCode(), data)
+	}
+
+	r, err := NewBillReader(body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	c.Logger.Debug("DownloadBill", c.Logger.Field("size", resp.Header().Get("Content-Length")), c.Logger.Field("mchId", c.MchId))
+
+	h := sha1.New()
+	return &billReader{
+		Reader:    io.TeeReader(r, h),
+		body:      body,
+		hash:      h,
+		hashValue: bill.HashValue,
+	}, nil
+}
+
+type billRecord struct {
+	header map[string]int
+	fields []string
+	err    error
+}
+
+func (r *billRecord) get(name string) string {
+	i, ok := r.header[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return r.fields[i]
+}
+
+func (r *billRecord) amount(name string) int64 {
+	v, err := ParseYuan(r.get(name))
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("%s: %w", name, err)
+	}
+	return v
+}
+
+func (r *billRecord) int(name string) int {
+	s := r.get(name)
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("%s: %w", name, err)
+	}
+	return v
+}
+
+func ParseYuan(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	integer, fraction, _ := strings.Cut(s, ".")
+	if len(fraction) > 2 {
+		return 0, errors.New("amount invalid:" + s)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+	if integer == "" {
+		integer = "0"
+	}
+	v, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil {
+		return 0, errors.New("amount invalid:" + s)
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+func billHeader(fields []string) map[string]int {
+	header := make(map[string]int, len(fields))
+	for i, field := range fields {
+		header[strings.NewReplacer("（", "(", "）", ")").Replace(field)] = i
+	}
+	return header
+}
+
+func readBill(r io.Reader, summaryKey string, onRow func(record *billRecord) error, onSummary func(record *billRecord) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var header map[string]int
+	var summary bool
+	for line := 1; ; line++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for i, field := range fields {
+			fields[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(field, "\ufeff"), "`"))
+		}
+		if len(fields) == 0 || (len(fields) == 1 && fields[0] == "") {
+			continue
+		}
+
+		switch {
+		case header == nil:
+			header = billHeader(fields)
+		case fields[0] == summaryKey:
+			header = billHeader(fields)
+			summary = true
+		default:
+			record := &billRecord{header: header, fields: fields}
+			if summary {
+				err = onSummary(record)
+			} else {
+				err = onRow(record)
+			}
+			if err == nil {
+				err = record.err
+			}
+			if err != nil {
+				return fmt.Errorf("bill line %d: %w", line, err)
+			}
+		}
+	}
+	return nil
+}
+
+type TradeBillRow struct {
+	TradeTime       string       `json:"tradeTime"`
+	AppId           string       `json:"appId"`
+	MchId           string       `json:"mchId"`
+	SubMchId        string       `json:"subMchId"`
+	DeviceInfo      string       `json:"deviceInfo"`
+	TransactionId   string       `json:"transactionId"`
+	OutTradeNo      string       `json:"outTradeNo"`
+	OpenId          string       `json:"openId"`
+	TradeType       TradeType    `json:"tradeType"`
+	TradeState      TradeState   `json:"tradeState"`
+	BankType        string       `json:"bankType"`
+	Currency        string       `json:"currency"`
+	SettlementTotal int64        `json:"settlementTotal"`
+	CouponAmount    int64        `json:"couponAmount"`
+	RefundId        string       `json:"refundId"`
+	OutRefundNo     string       `json:"outRefundNo"`
+	Refund          int64        `json:"refund"`
+	CouponRefund    int64        `json:"couponRefund"`
+	RefundType      string       `json:"refundType"`
+	RefundStatus    RefundStatus `json:"refundStatus"`
+	Body            string       `json:"body"`
+	Attach          string       `json:"attach"`
+	Fee             int64        `json:"fee"`
+	Rate            string       `json:"rate"`
+	Total           int64        `json:"total"`
+	RequestRefund   int64        `json:"requestRefund"`
+	RateRemark      string       `json:"rateRemark"`
+}
+
+type TradeBillSummary struct {
+	TotalCount      int   `json:"totalCount"`
+	SettlementTotal int64 `json:"settlementTotal"`
+	Refund          int64 `json:"refund"`
+	CouponRefund    int64 `json:"couponRefund"`
+	Fee             int64 `json:"fee"`
+	Total           int64 `json:"total"`
+	RequestRefund   int64 `json:"requestRefund"`
+}
+
+func ParseTradeBill(r io.Reader, fn func(row *TradeBillRow) error) (*TradeBillSummary, error) {
+	var summary *TradeBillSummary
+	var computed = &TradeBillSummary{}
+	err := readBill(r, "总交易单数", func(record *billRecord) error {
+		row := &TradeBillRow{
+			TradeTime:       record.get("交易时间"),
+			AppId:           record.get("公众账号ID"),
+			MchId:           record.get("商户号"),
+			SubMchId:        record.get("特约商户号"),
+			DeviceInfo:      record.get("设备号"),
+			TransactionId:   record.get("微信订单号"),
+			OutTradeNo:      record.get("商户订单号"),
+			OpenId:          record.get("用户标识"),
+			TradeType:       TradeType(record.get("交易类型")),
+			TradeState:      TradeState(record.get("交易状态")),
+			BankType:        record.get("付款银行"),
+			Currency:        record.get("货币种类"),
+			SettlementTotal: record.amount("应结订单金额"),
+			CouponAmount:    record.amount("代金券金额"),
+			RefundId:        record.get("微信退款单号"),
+			OutRefundNo:     record.get("商户退款单号"),
+			Refund:          record.amount("退款金额"),
+			CouponRefund:    record.amount("充值券退款金额"),
+			RefundType:      record.get("退款类型"),
+			RefundStatus:    RefundStatus(record.get("退款状态")),
+			Body:            record.get("商品名称"),
+			Attach:          record.get("商户数据包"),
+			Fee:             record.amount("手续费"),
+			Rate:            record.get("费率"),
+			Total:           record.amount("订单金额"),
+			RequestRefund:   record.amount("申请退款金额"),
+			RateRemark:      record.get("费率备注"),
+		}
+		if record.err != nil {
+			return record.err
+		}
+		computed.TotalCount++
+		computed.SettlementTotal += row.SettlementTotal
+		computed.Refund += row.Refund
+		computed.CouponRefund += row.CouponRefund
+		computed.Fee += row.Fee
+		computed.Total += row.Total
+		computed.RequestRefund += row.RequestRefund
+		if fn == nil {
+			return nil
+		}
+		return fn(row)
+	}, func(record *billRecord) error {
+		summary = &TradeBillSummary{
+			TotalCount:      record.int("总交易单数"),
+			SettlementTotal: record.amount("应结订单总金额"),
+			Refund:          record.amount("退款总金额"),
+			CouponRefund:    record.amount("充值券退款总金额"),
+			Fee:             record.amount("手续费总金额"),
+			Total:           record.amount("订单总金额"),
+			RequestRefund:   record.amount("申请退款总金额"),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil {
+		return nil, errors.New("bill summary 不存在")
+	}
+	if *summary != *computed {
+		return summary, fmt.Errorf("bill summary mismatch: summary %+v, computed %+v", *summary, *computed)
+	}
+	return summary, nil
+}
+
+type FundFlowBillRow struct {
+	AccountTime  string `json:"accountTime"`
+	BizOrderId   string `json:"bizOrderId"`
+	FlowId       string `json:"flowId"`
+	BizName      string `json:"bizName"`
+	BizType      string `json:"bizType"`
+	FlowType     string `json:"flowType"`
+	Amount       int64  `json:"amount"`
+	Balance      int64  `json:"balance"`
+	Applicant    string `json:"applicant"`
+	Remark       string `json:"remark"`
+	BizVoucherId string `json:"bizVoucherId"`
+}
+
+func (r *FundFlowBillRow) Income() bool {
+	return r.FlowType == "收入"
+}
+
+type FundFlowBillSummary struct {
+	TotalCount    int   `json:"totalCount"`
+	IncomeCount   int   `json:"incomeCount"`
+	IncomeAmount  int64 `json:"incomeAmount"`
+	ExpenseCount  int   `json:"expenseCount"`
+	ExpenseAmount int64 `json:"expenseAmount"`
+}
+
+func ParseFundFlowBill(r io.Reader, fn func(row *FundFlowBillRow) error) (*FundFlowBillSummary, error) {
+	var summary *FundFlowBillSummary
+	var computed = &FundFlowBillSummary{}
+	err := readBill(r, "资金流水总笔数", func(record *billRecord) error {
+		row := &FundFlowBillRow{
+			AccountTime:  record.get("记账时间"),
+			BizOrderId:   record.get("微信支付业务单号"),
+			FlowId:       record.get("资金流水单号"),
+			BizName:      record.get("业务名称"),
+			BizType:      record.get("业务类型"),
+			FlowType:     record.get("收支类型"),
+			Amount:       record.amount("收支金额(元)"),
+			Balance:      record.amount("账户结余(元)"),
+			Applicant:    record.get("资金变更提交申请人"),
+			Remark:       record.get("备注"),
+			BizVoucherId: record.get("业务凭证号"),
+		}
+		if record.err != nil {
+			return record.err
+		}
+		computed.TotalCount++
+		if row.Income() {
+			computed.IncomeCount++
+			computed.IncomeAmount += row.Amount
+		} else {
+			computed.ExpenseCount++
+			computed.ExpenseAmount += row.Amount
+		}
+		if fn == nil {
+			return nil
+		}
+		return fn(row)
+	}, func(record *billRecord) error {
+		summary = &FundFlowBillSummary{
+			TotalCount:    record.int("资金流水总笔数"),
+			IncomeCount:   record.int("收入笔数"),
+			IncomeAmount:  record.amount("收入金额"),
+			ExpenseCount:  record.int("支出笔数"),
+			ExpenseAmount: record.amount("支出金额"),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil {
+		return nil, errors.New("bill summary 不存在")
+	}
+	if *summary != *computed {
+		return summary, fmt.Errorf("bill summary mismatch: summary %+v, computed %+v", *summary, *computed)
+	}
+	return summary, nil
+}
@@ -0,0 +1,126 @@
+package pay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testTradeBill = "\ufeff交易时间,公众账号ID,商户号,特约商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券金额,微信退款单号,商户退款单号,退款金额,充值券退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额,费率备注\n" +
+	"`2024-03-01 10:30:00,`wx8f3c9c583c35ebd5,`1230000109,`0,`,`4200002156202403012345678901,`1217752501201407033233368018,`oasi95rPit953LHRYfaifGnTuqgs,`JSAPI,`SUCCESS,`OTHERS,`CNY,`1.00,`0.00,`0,`0,`0.00,`0.00,`,`,`description,`,`0.01,`0.60%,`1.00,`0.00,`\n" +
+	"`2024-03-01 11:00:00,`wx8f3c9c583c35ebd5,`1230000109,`0,`,`4200002156202403012345678902,`1217752501201407033233368019,`oasi95rPit953LHRYfaifGnTuqgs,`JSAPI,`REFUND,`OTHERS,`CNY,`0.00,`0.00,`50000000382019052709732678859,`1217752501201407033233368019,`0.50,`0.00,`ORIGINAL,`SUCCESS,`description,`,`-0.00,`0.60%,`0.00,`0.50,`\n" +
+	"总交易单数,应结订单总金额,退款总金额,充值券退款总金额,手续费总金额,订单总金额,申请退款总金额\n" +
+	"`2,`1.00,`0.50,`0.00,`0.01,`1.00,`0.50\n"
+
+const testFundFlowBill = "记账时间,微信支付业务单号,资金流水单号,业务名称,业务类型,收支类型,收支金额（元）,账户结余（元）,资金变更提交申请人,备注,业务凭证号\n" +
+	"`2024-03-01 10:30:00,`4200002156202403012345678901,`4200002156202403012345678901,`交易,`交易,`收入,`1.00,`101.00,`system,`,`REF4200002156202403012345678901\n" +
+	"`2024-03-01 10:30:00,`4200002156202403012345678901,`4200002156202403012345678901,`扣除交易手续费,`扣除交易手续费,`支出,`0.01,`100.99,`system,`,`REF4200002156202403012345678901\n" +
+	"资金流水总笔数,收入笔数,收入金额,支出笔数,支出金额\n" +
+	"`2,`1,`1.00,`1,`0.01\n"
+
+func TestParseYuan(t *testing.T) {
+	for s, v := range map[string]int64{"1.00": 100, "0.5": 50, "-0.01": -1, "12": 1200, "": 0} {
+		result, err := ParseYuan(s)
+		if err != nil || result != v {
+			t.Fatal(s, result, err)
+		}
+	}
+	if _, err := ParseYuan("0.001"); err == nil {
+		t.Fatal("invalid amount accepted")
+	}
+}
+
+func TestParseTradeBill(t *testing.T) {
+	var rows []*TradeBillRow
+	summary, err := ParseTradeBill(strings.NewReader(testTradeBill), func(row *TradeBillRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].SettlementTotal != 100 || rows[0].Fee != 1 || rows[1].TradeState != TradeStateRefund || rows[1].Refund != 50 {
+		t.Fatal("rows", rows)
+	}
+	if summary.TotalCount != 2 || summary.SettlementTotal != 100 || summary.Refund != 50 {
+		t.Fatal("summary", summary)
+	}
+
+	mismatch := strings.Replace(testTradeBill, "`2,`1.00", "`2,`2.00", 1)
+	if _, err := ParseTradeBill(strings.NewReader(mismatch), nil); err == nil {
+		t.Fatal("summary mismatch accepted")
+	}
+}
+
+func TestParseFundFlowBill(t *testing.T) {
+	summary, err := ParseFundFlowBill(strings.NewReader(testFundFlowBill), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.IncomeAmount != 100 || summary.ExpenseAmount != 1 {
+		t.Fatal("summary", summary)
+	}
+}
+
+func TestDownloadBill(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(testTradeBill))
+	gz.Close()
+	hash := sha1.Sum([]byte(testTradeBill))
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/bill/tradebill":
+			if r.URL.Query().Get("bill_date") != "2024-03-01" || r.URL.Query().Get("tar_type") != "GZIP" {
+				writeTestResponse(w, platformPublicKeyId, http.StatusBadRequest, `{"code":"PARAM_ERROR","message":"参数错误"}`)
+				return
+			}
+			writeTestResponse(w, platformPublicKeyId, http.StatusOK, `{"hash_type":"SHA1","hash_value":"`+hex.EncodeToString(hash[:])+`","download_url":"`+server.URL+`/v3/billdownload/file?token=6XIv5TUPto7pByrTQKhd6kwvyKLG2uY2wMMR8cNXqaA_Cv_isgaUtBzp4QtiozLO"}`)
+		case "/v3/billdownload/file":
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "WECHATPAY2-SHA256-RSA2048 ") {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write(compressed.Bytes())
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	bill, err := client.TradeBill(&TradeBillReq{
+		BillDate: "2024-03-01",
+		TarType:  "GZIP",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := client.DownloadBill(bill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := ParseTradeBill(body, nil)
+	body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.TotalCount != 2 {
+		t.Fatal("summary", summary)
+	}
+
+	bill.HashValue = strings.Repeat("0", 40)
+	body, err = client.DownloadBill(bill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	if _, err := ParseTradeBill(body, nil); err == nil {
+		t.Fatal("invalid hash accepted")
+	}
+}
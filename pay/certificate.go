@@ -0,0 +1,256 @@
+package pay
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	CertificatePrefix          = "wx-pay-certificates:"
+	CertificateExpire          = time.Hour * 12
+	CertificateRefreshInterval = time.Minute
+)
+
+type EncryptCertificate struct {
+	Algorithm      string `json:"algorithm"`
+	Nonce          string `json:"nonce"`
+	AssociatedData string `json:"associated_data"`
+	Ciphertext     string `json:"ciphertext"`
+}
+
+type CertificateData struct {
+	SerialNo           string              `json:"serial_no"`
+	EffectiveTime      string              `json:"effective_time"`
+	ExpireTime         string              `json:"expire_time"`
+	EncryptCertificate *EncryptCertificate `json:"encrypt_certificate"`
+}
+
+type CertificatesRes struct {
+	Data []*CertificateData `json:"data"`
+}
+
+func NewCertificateManager(c *Client) *CertificateManager {
+	return &CertificateManager{
+		client:   c,
+		verifier: NewCertificateVerifier(),
+	}
+}
+
+type CertificateManager struct {
+	client      *Client
+	lock        sync.Mutex
+	verifier    *CertificateVerifier
+	loaded      bool
+	expiresAt   time.Time
+	lastRefresh time.Time
+}
+
+func (m *CertificateManager) ClearCertificates() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.clearCertificates()
+}
+
+// the previous certificates stay loaded as a fallback until a download succeeds
+func (m *CertificateManager) clearCertificates() {
+	m.expiresAt = time.Time{}
+}
+
+func (m *CertificateManager) SetCertificates(certificates []*x509.Certificate, expiresIn int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.setCertificates(certificates, expiresIn)
+}
+
+func (m *CertificateManager) setCertificates(certificates []*x509.Certificate, expiresIn int64) {
+	m.verifier = NewCertificateVerifier(certificates...)
+	m.loaded = true
+	m.expiresAt = time.Now().Add(time.Second * time.Duration(expiresIn))
+}
+
+func encodeCertificates(certificates []*x509.Certificate) ([]byte, error) {
+	var values = make(map[string]string)
+	for _, certificate := range certificates {
+		values[certificateSerial(certificate)] = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+	}
+	return json.Marshal(values)
+}
+
+func decodeCertificates(data []byte) ([]*x509.Certificate, error) {
+	var values = make(map[string]string)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	var certificates []*x509.Certificate
+	for _, value := range values {
+		certificate, err := ParseCertificate(value)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
+
+func (m *CertificateManager) DownloadCertificates() ([]*x509.Certificate, error) {
+	var res = &CertificatesRes{}
+	resp, err := m.client.request("GET", "/v3/certificates", nil, res)
+	if err != nil {
+		return nil, err
+	}
+
+	var certificates []*x509.Certificate
+	for _, data := range res.Data {
+		if data.EncryptCertificate == nil {
+			continue
+		}
+		plain, err := m.client.DecryptResource(&NotifyResource{
+			Algorithm:      data.EncryptCertificate.Algorithm,
+			Nonce:          data.EncryptCertificate.Nonce,
+			AssociatedData: data.EncryptCertificate.AssociatedData,
+			Ciphertext:     data.EncryptCertificate.Ciphertext,
+		})
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := ParseCertificate(string(plain))
+		if err != nil {
+			return nil, err
+		}
+		if certificateSerial(certificate) != data.SerialNo {
+			return nil, errors.New(fmt.Sprintf("certificate serial mismatch:%s", data.SerialNo))
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("certificates 不存在")
+	}
+
+	if err := verifyResponse(NewCertificateVerifier(certificates...), resp.Header(), resp.Body()); err != nil {
+		return nil, err
+	}
+	return certificates, nil
+}
+
+func (m *CertificateManager) GetCertificates() (v *CertificateVerifier, err error) {
+
+	m.lock.Lock()
+	defer func() {
+		if err != nil {
+			m.client.Logger.Error("GetCertificates", m.client.Logger.Field("error", err), m.client.Logger.Field("mchId", m.client.MchId))
+		}
+		m.lock.Unlock()
+	}()
+
+	if time.Now().Before(m.expiresAt) {
+		m.client.Logger.Debug("GetCertificates from application", m.client.Logger.Field("mchId", m.client.MchId))
+		return m.verifier, nil
+	}
+
+	if m.client.Redis != nil {
+		data, _ := m.client.Redis.Get(context.Background(), CertificatePrefix+m.client.MchId).Bytes()
+		ttl, _ := m.client.Redis.TTL(context.Background(), CertificatePrefix+m.client.MchId).Result()
+		if len(data) > 0 && ttl > 0 {
+			if certificates, err := decodeCertificates(data); err == nil {
+				m.setCertificates(certificates, int64(ttl/time.Second))
+				m.client.Logger.Debug("GetCertificates from redis", m.client.Logger.Field("mchId", m.client.MchId))
+				return m.verifier, nil
+			}
+		}
+	}
+
+	if time.Since(m.lastRefresh) < CertificateRefreshInterval {
+		if m.loaded {
+			return m.verifier, nil
+		}
+		return nil, errors.New("certificates refreshed recently")
+	}
+	if err := m.download(); err != nil {
+		if !m.loaded {
+			return nil, err
+		}
+		m.client.Logger.Warn("GetCertificates stale", m.client.Logger.Field("error", err), m.client.Logger.Field("mchId", m.client.MchId))
+		return m.verifier, nil
+	}
+	m.client.Logger.Debug("GetCertificates from request", m.client.Logger.Field("mchId", m.client.MchId))
+	return m.verifier, nil
+}
+
+func (m *CertificateManager) download() error {
+	m.lastRefresh = time.Now()
+	certificates, err := m.DownloadCertificates()
+	if err != nil {
+		return err
+	}
+
+	m.setCertificates(certificates, int64(CertificateExpire/time.Second))
+
+	if m.client.Redis != nil {
+		if data, err := encodeCertificates(certificates); err == nil {
+			m.client.Redis.Set(context.Background(), CertificatePrefix+m.client.MchId, data, CertificateExpire).Result()
+		}
+	}
+	return nil
+}
+
+func (m *CertificateManager) RefreshCertificates() (err error) {
+	m.lock.Lock()
+	defer func() {
+		if err != nil {
+			m.client.Logger.Error("RefreshCertificates", m.client.Logger.Field("error", err), m.client.Logger.Field("mchId", m.client.MchId))
+		}
+		m.lock.Unlock()
+	}()
+
+	if time.Since(m.lastRefresh) < CertificateRefreshInterval {
+		return errors.New("certificates refreshed recently")
+	}
+	return m.download()
+}
+
+func (m *CertificateManager) Certificate(serial string) (*x509.Certificate, error) {
+	verifier, err := m.GetCertificates()
+	if err != nil {
+		return nil, err
+	}
+	if certificate, ok := verifier.Certificate(serial); ok {
+		return certificate, nil
+	}
+	if err := m.RefreshCertificates(); err != nil {
+		return nil, errors.New("certificate not found:" + serial)
+	}
+	verifier, err = m.GetCertificates()
+	if err != nil {
+		return nil, err
+	}
+	if certificate, ok := verifier.Certificate(serial); ok {
+		return certificate, nil
+	}
+	return nil, errors.New("certificate not found:" + serial)
+}
+
+func (m *CertificateManager) Verify(serial string, message string, signature string) error {
+	if _, err := m.Certificate(serial); err != nil {
+		return err
+	}
+	verifier, err := m.GetCertificates()
+	if err != nil {
+		return err
+	}
+	return verifier.Verify(serial, message, signature)
+}
+
+func (m *CertificateManager) EncryptKey() (string, *rsa.PublicKey, error) {
+	verifier, err := m.GetCertificates()
+	if err != nil {
+		return "", nil, err
+	}
+	return verifier.EncryptKey()
+}
@@ -0,0 +1,195 @@
+package pay
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestCertificate(t *testing.T) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x5157F09EFDC096DE),
+		Subject:      pkix.Name{CommonName: "Tenpay.com Root CA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour * 24 * 365),
+	}
+	data, err := x509.CreateCertificate(rand.Reader, template, template, &platformKey.PublicKey, platformKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func TestCertificateManager(t *testing.T) {
+	certificate := newTestCertificate(t)
+	serial := certificateSerial(certificate)
+
+	var downloads int
+	var client *Client
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/certificates":
+			downloads++
+			resource := encryptTestResource(client.ApiV3Key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})))
+			body, _ := json.Marshal(&CertificatesRes{
+				Data: []*CertificateData{
+					{
+						SerialNo:      serial,
+						EffectiveTime: "2018-06-08T10:34:56+08:00",
+						ExpireTime:    "2023-06-08T10:34:56+08:00",
+						EncryptCertificate: &EncryptCertificate{
+							Algorithm:      resource.Algorithm,
+							Nonce:          resource.Nonce,
+							AssociatedData: resource.AssociatedData,
+							Ciphertext:     resource.Ciphertext,
+						},
+					},
+				},
+			})
+			writeTestResponse(w, serial, http.StatusOK, string(body))
+		default:
+			writeTestResponse(w, serial, http.StatusOK, `{"code_url":"weixin://wxpay/bizpayurl?pr=p4lpSuKzz"}`)
+		}
+	}))
+	defer server.Close()
+
+	client = newTestClient(server.URL)
+	client.Verifier = NewCertificateManager(client)
+
+	for i := 0; i < 2; i++ {
+		result, err := client.NativePrepay(&PrepayReq{
+			Description: "description",
+			OutTradeNo:  fmt.Sprintf("121775250120140703323336801%d", i),
+			Amount: &Amount{
+				Total: 100,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log("result", result)
+	}
+	if downloads != 1 {
+		t.Fatal("downloads", downloads)
+	}
+}
+
+func TestCertificateManagerUnknownSerial(t *testing.T) {
+	certificate := newTestCertificate(t)
+	serial := certificateSerial(certificate)
+
+	var downloads int
+	var client *Client
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		resource := encryptTestResource(client.ApiV3Key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})))
+		body, _ := json.Marshal(&CertificatesRes{
+			Data: []*CertificateData{
+				{
+					SerialNo: serial,
+					EncryptCertificate: &EncryptCertificate{
+						Algorithm:      resource.Algorithm,
+						Nonce:          resource.Nonce,
+						AssociatedData: resource.AssociatedData,
+						Ciphertext:     resource.Ciphertext,
+					},
+				},
+			},
+		})
+		writeTestResponse(w, serial, http.StatusOK, string(body))
+	}))
+	defer server.Close()
+
+	client = newTestClient(server.URL)
+	manager := NewCertificateManager(client)
+
+	if _, err := manager.Certificate(serial); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := manager.Certificate("5157F09EFDC096DE15EBE81A47057A72"); err == nil {
+			t.Fatal("unknown serial accepted")
+		}
+	}
+	if downloads != 1 {
+		t.Fatal("downloads", downloads)
+	}
+
+	manager.lastRefresh = manager.lastRefresh.Add(-CertificateRefreshInterval)
+	if _, err := manager.Certificate("5157F09EFDC096DE15EBE81A47057A72"); err == nil {
+		t.Fatal("unknown serial accepted")
+	}
+	if downloads != 2 {
+		t.Fatal("downloads", downloads)
+	}
+	if _, err := manager.Certificate(serial); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertificateManagerStale(t *testing.T) {
+	certificate := newTestCertificate(t)
+	serial := certificateSerial(certificate)
+
+	var downloads int
+	var client *Client
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		if downloads > 1 {
+			writeTestResponse(w, serial, http.StatusInternalServerError, `{"code":"SYSTEM_ERROR","message":"系统错误"}`)
+			return
+		}
+		resource := encryptTestResource(client.ApiV3Key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})))
+		body, _ := json.Marshal(&CertificatesRes{
+			Data: []*CertificateData{
+				{
+					SerialNo: serial,
+					EncryptCertificate: &EncryptCertificate{
+						Algorithm:      resource.Algorithm,
+						Nonce:          resource.Nonce,
+						AssociatedData: resource.AssociatedData,
+						Ciphertext:     resource.Ciphertext,
+					},
+				},
+			},
+		})
+		writeTestResponse(w, serial, http.StatusOK, string(body))
+	}))
+	defer server.Close()
+
+	client = newTestClient(server.URL)
+	manager := NewCertificateManager(client)
+	if _, err := manager.Certificate(serial); err != nil {
+		t.Fatal(err)
+	}
+
+	manager.ClearCertificates()
+	if _, err := manager.Certificate(serial); err != nil {
+		t.Fatal(err)
+	}
+	if downloads != 1 {
+		t.Fatal("downloads", downloads)
+	}
+
+	manager.lastRefresh = manager.lastRefresh.Add(-CertificateRefreshInterval)
+	for i := 0; i < 3; i++ {
+		if _, err := manager.Certificate(serial); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if downloads != 2 {
+		t.Fatal("downloads", downloads)
+	}
+}
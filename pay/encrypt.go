@@ -0,0 +1,41 @@
+package pay
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+)
+
+func EncryptOAEP(publicKey *rsa.PublicKey, plain string) (string, error) {
+	ciphertext, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, publicKey, []byte(plain), nil)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func DecryptOAEP(privateKey *rsa.PrivateKey, ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	plain, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, privateKey, data, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (c *Client) EncryptKey() (string, *rsa.PublicKey, error) {
+	encryptor, ok := c.Verifier.(Encryptor)
+	if !ok {
+		return "", nil, errors.New("Verifier 不支持加密")
+	}
+	return encryptor.EncryptKey()
+}
+
+func (c *Client) Decrypt(ciphertext string) (string, error) {
+	return DecryptOAEP(c.privateKey, ciphertext)
+}
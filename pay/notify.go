@@ -0,0 +1,202 @@
+package pay
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	localTime "github.com/go-tron/local-time"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const NotifyTimestampExpire = time.Minute * 5
+
+type NotifyResource struct {
+	Algorithm      string `json:"algorithm"`
+	Ciphertext     string `json:"ciphertext"`
+	AssociatedData string `json:"associated_data"`
+	OriginalType   string `json:"original_type"`
+	Nonce          string `json:"nonce"`
+}
+
+type Notify struct {
+	Id           string          `json:"id"`
+	CreateTime   string          `json:"create_time"`
+	EventType    string          `json:"event_type"`
+	ResourceType string          `json:"resource_type"`
+	Summary      string          `json:"summary"`
+	Resource     *NotifyResource `json:"resource"`
+}
+
+type RefundNotifyAmount struct {
+	Total       int64 `json:"total"`
+	Refund      int64 `json:"refund"`
+	PayerTotal  int64 `json:"payer_total"`
+	PayerRefund int64 `json:"payer_refund"`
+}
+
+type RefundNotify struct {
+	MchId               string              `json:"mchid"`
+	OutTradeNo          string              `json:"out_trade_no"`
+	TransactionId       string              `json:"transaction_id"`
+	OutRefundNo         string              `json:"out_refund_no"`
+	RefundId            string              `json:"refund_id"`
+	RefundStatus        RefundStatus        `json:"refund_status"`
+	SuccessTime         string              `json:"success_time"`
+	UserReceivedAccount string              `json:"user_received_account"`
+	Amount              *RefundNotifyAmount `json:"amount"`
+}
+
+func DecryptAes256Gcm(key string, nonce string, associatedData string, ciphertext string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("nonce invalid")
+	}
+	return gcm.Open(nil, []byte(nonce), data, []byte(associatedData))
+}
+
+func (c *Client) DecryptResource(resource *NotifyResource) ([]byte, error) {
+	if resource == nil {
+		return nil, errors.New("resource 不存在")
+	}
+	if resource.Algorithm != "AEAD_AES_256_GCM" {
+		return nil, errors.New("algorithm not supported:" + resource.Algorithm)
+	}
+	return DecryptAes256Gcm(c.ApiV3Key, resource.Nonce, resource.AssociatedData, resource.Ciphertext)
+}
+
+func verifyResponse(verifier Verifier, header http.Header, body []byte) error {
+	timestamp := header.Get("Wechatpay-Timestamp")
+	nonce := header.Get("Wechatpay-Nonce")
+	signature := header.Get("Wechatpay-Signature")
+	serial := header.Get("Wechatpay-Serial")
+	if timestamp == "" || nonce == "" || signature == "" || serial == "" {
+		return errors.New("signature header 不存在")
+	}
+	if strings.HasPrefix(signature, "WECHATPAY/SIGNTEST/") {
+		return errors.New("signature is a sign test")
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return err
+	}
+	if d := localTime.Now().Unix() - ts; d > int64(NotifyTimestampExpire/time.Second) || -d > int64(NotifyTimestampExpire/time.Second) {
+		return errors.New("timestamp expired")
+	}
+	return verifier.Verify(serial, timestamp+"\n"+nonce+"\n"+string(body)+"\n", signature)
+}
+
+func (c *Client) VerifyHeader(header http.Header, body []byte) error {
+	if c.Verifier == nil {
+		return errors.New("Verifier 必须设置")
+	}
+	return verifyResponse(c.Verifier, header, body)
+}
+
+func (c *Client) ParseNotify(r *http.Request) (*Notify, []byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.VerifyHeader(r.Header, body); err != nil {
+		return nil, nil, err
+	}
+
+	var notify = &Notify{}
+	if err := json.Unmarshal(body, notify); err != nil {
+		return nil, nil, err
+	}
+	data, err := c.DecryptResource(notify.Resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.Logger.Debug("ParseNotify", c.Logger.Field("eventType", notify.EventType), c.Logger.Field("resource", data), c.Logger.Field("mchId", c.MchId))
+	return notify, data, nil
+}
+
+func (c *Client) ParseTransactionNotify(r *http.Request) (*Notify, *Transaction, error) {
+	notify, data, err := c.ParseNotify(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var transaction = &Transaction{}
+	if err := json.Unmarshal(data, transaction); err != nil {
+		return nil, nil, err
+	}
+	return notify, transaction, nil
+}
+
+func (c *Client) ParseRefundNotify(r *http.Request) (*Notify, *RefundNotify, error) {
+	notify, data, err := c.ParseNotify(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var refund = &RefundNotify{}
+	if err := json.Unmarshal(data, refund); err != nil {
+		return nil, nil, err
+	}
+	return notify, refund, nil
+}
+
+type NotifyHandler struct {
+	Client        *Client
+	OnTransaction func(notify *Notify, transaction *Transaction) error
+	OnRefund      func(notify *Notify, refund *RefundNotify) error
+}
+
+func writeNotifyFail(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	data, _ := json.Marshal(map[string]string{
+		"code":    "FAIL",
+		"message": message,
+	})
+	w.Write(data)
+}
+
+func (h *NotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	notify, data, err := h.Client.ParseNotify(r)
+	if err != nil {
+		h.Client.Logger.Error("NotifyHandler", h.Client.Logger.Field("error", err), h.Client.Logger.Field("mchId", h.Client.MchId))
+		writeNotifyFail(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(notify.EventType, "TRANSACTION.") && h.OnTransaction != nil:
+		var transaction = &Transaction{}
+		if err = json.Unmarshal(data, transaction); err == nil {
+			err = h.OnTransaction(notify, transaction)
+		}
+	case strings.HasPrefix(notify.EventType, "REFUND.") && h.OnRefund != nil:
+		var refund = &RefundNotify{}
+		if err = json.Unmarshal(data, refund); err == nil {
+			err = h.OnRefund(notify, refund)
+		}
+	default:
+		err = errors.New("event type not handled:" + notify.EventType)
+	}
+
+	if err != nil {
+		h.Client.Logger.Error("NotifyHandler", h.Client.Logger.Field("error", err), h.Client.Logger.Field("eventType", notify.EventType), h.Client.Logger.Field("mchId", h.Client.MchId))
+		writeNotifyFail(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package pay
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	localTime "github.com/go-tron/local-time"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func encryptTestResource(key string, plain string) *NotifyResource {
+	block, _ := aes.NewCipher([]byte(key))
+	gcm, _ := cipher.NewGCM(block)
+	nonce := "fdasflkja484"
+	return &NotifyResource{
+		Algorithm:      "AEAD_AES_256_GCM",
+		Ciphertext:     base64.StdEncoding.EncodeToString(gcm.Seal(nil, []byte(nonce), []byte(plain), []byte("transaction"))),
+		AssociatedData: "transaction",
+		OriginalType:   "transaction",
+		Nonce:          nonce,
+	}
+}
+
+func newTestNotifyRequest(body []byte, serial string) *http.Request {
+	timestamp := strconv.FormatInt(localTime.Now().Unix(), 10)
+	nonce := "5K8264ILTKCH16CQ2502SI8ZNMTM67VS"
+	hashed := sha256.Sum256([]byte(timestamp + "\n" + nonce + "\n" + string(body) + "\n"))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, platformKey, crypto.SHA256, hashed[:])
+
+	r := httptest.NewRequest(http.MethodPost, "/pay/notify", strings.NewReader(string(body)))
+	r.Header.Set("Wechatpay-Timestamp", timestamp)
+	r.Header.Set("Wechatpay-Nonce", nonce)
+	r.Header.Set("Wechatpay-Signature", base64.StdEncoding.EncodeToString(signature))
+	r.Header.Set("Wechatpay-Serial", serial)
+	return r
+}
+
+func TestNotifyHandler(t *testing.T) {
+	client := newTestClient("")
+
+	body, _ := json.Marshal(&Notify{
+		Id:           "EV-2018022511223320873",
+		CreateTime:   "2015-05-20T13:29:35+08:00",
+		EventType:    "TRANSACTION.SUCCESS",
+		ResourceType: "encrypt-resource",
+		Summary:      "支付成功",
+		Resource:     encryptTestResource(client.ApiV3Key, `{"mchid":"1230000109","appid":"wx8f3c9c583c35ebd5","out_trade_no":"1217752501201407033233368018","transaction_id":"1217752501201407033233368018","trade_type":"JSAPI","trade_state":"SUCCESS","success_time":"2018-06-08T10:34:56+08:00","payer":{"openid":"oasi95rPit953LHRYfaifGnTuqgs"},"amount":{"total":100,"payer_total":100,"currency":"CNY","payer_currency":"CNY"}}`),
+	})
+
+	var result *Transaction
+	handler := &NotifyHandler{
+		Client: client,
+		OnTransaction: func(notify *Notify, transaction *Transaction) error {
+			result = transaction
+			return nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newTestNotifyRequest(body, platformPublicKeyId))
+	if w.Code != http.StatusNoContent {
+		t.Fatal("status", w.Code, w.Body.String())
+	}
+	if result == nil || result.TradeState != TradeStateSuccess || result.Amount.Total != 100 {
+		t.Fatal("result", result)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newTestNotifyRequest(body, "PUB_KEY_ID_0112340000000002"))
+	if w.Code == http.StatusNoContent {
+		t.Fatal("unknown serial accepted")
+	}
+	t.Log("result", result)
+}
@@ -0,0 +1,221 @@
+package pay
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/go-tron/config"
+	localTime "github.com/go-tron/local-time"
+	"github.com/go-tron/logger"
+	"github.com/go-tron/random"
+	"github.com/go-tron/redis"
+	"strconv"
+)
+
+const BaseUrl = "https://api.mch.weixin.qq.com"
+
+func NewWithConfig(c *config.Config, redis *redis.Redis) *Client {
+	conf := &Config{
+		AppId:      c.GetString("weixin.appId"),
+		MchId:      c.GetString("weixin.pay.mchId"),
+		SerialNo:   c.GetString("weixin.pay.serialNo"),
+		PrivateKey: c.GetString("weixin.pay.privateKey"),
+		ApiV3Key:   c.GetString("weixin.pay.apiV3Key"),
+		NotifyUrl:  c.GetString("weixin.pay.notifyUrl"),
+		Redis:      redis,
+		Logger:     logger.NewZapWithConfig(c, "weixin-pay", "info"),
+	}
+	if publicKey := c.GetString("weixin.pay.publicKey"); publicKey != "" {
+		verifier, err := NewPublicKeyVerifier(c.GetString("weixin.pay.publicKeyId"), publicKey)
+		if err != nil {
+			panic(err)
+		}
+		conf.Verifier = verifier
+	}
+	return New(conf)
+}
+
+func New(c *Config) *Client {
+
+	if c == nil {
+		panic("config 必须设置")
+	}
+	if c.AppId == "" {
+		panic("AppId 必须设置")
+	}
+	if c.MchId == "" {
+		panic("MchId 必须设置")
+	}
+	if c.SerialNo == "" {
+		panic("SerialNo 必须设置")
+	}
+	if c.PrivateKey == "" {
+		panic("PrivateKey 必须设置")
+	}
+	if c.ApiV3Key == "" {
+		panic("ApiV3Key 必须设置")
+	}
+	if c.Logger == nil {
+		panic("Logger 必须设置")
+	}
+	if c.BaseUrl == "" {
+		c.BaseUrl = BaseUrl
+	}
+
+	privateKey, err := ParsePrivateKey(c.PrivateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	client := &Client{
+		Config:     c,
+		privateKey: privateKey,
+	}
+	if client.Verifier == nil {
+		client.Verifier = NewCertificateManager(client)
+	}
+	return client
+}
+
+type Config struct {
+	AppId      string        `json:"appId"`
+	MchId      string        `json:"mchId"`
+	SerialNo   string        `json:"serialNo"`
+	PrivateKey string        `json:"privateKey"`
+	ApiV3Key   string        `json:"apiV3Key"`
+	NotifyUrl  string        `json:"notifyUrl"`
+	BaseUrl    string        `json:"baseUrl"`
+	Logger     logger.Logger `json:"logger"`
+	Redis      *redis.Redis  `json:"redis"`
+	Verifier   Verifier      `json:"-"`
+}
+
+type Client struct {
+	*Config
+	privateKey *rsa.PrivateKey
+}
+
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("(%s)%s", e.Code, e.Message)
+}
+
+func ParsePrivateKey(privateKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, errors.New("privateKey invalid")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("privateKey is not rsa")
+	}
+	return rsaKey, nil
+}
+
+func (c *Client) Sign(message string) (string, error) {
+	hashed := sha256.Sum256([]byte(message))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func (c *Client) authorization(method string, path string, body string) (string, error) {
+	timestamp := strconv.FormatInt(localTime.Now().Unix(), 10)
+	nonceStr := random.String(32)
+	message := method + "\n" + path + "\n" + timestamp + "\n" + nonceStr + "\n" + body + "\n"
+	signature, err := c.Sign(message)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`WECHATPAY2-SHA256-RSA2048 mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		c.MchId, nonceStr, signature, timestamp, c.SerialNo), nil
+}
+
+func decodeError(resp *resty.Response) error {
+	return decodeErrorBody(resp.StatusCode(), resp.Body())
+}
+
+func decodeErrorBody(statusCode int, body []byte) error {
+	if statusCode >= 200 && statusCode < 300 {
+		return nil
+	}
+	var e = &Error{StatusCode: statusCode}
+	if err := json.Unmarshal(body, e); err != nil || e.Code == "" {
+		e.Code = strconv.Itoa(statusCode)
+		e.Message = string(body)
+	}
+	return e
+}
+
+func (c *Client) request(method string, path string, body interface{}, res interface{}) (*resty.Response, error) {
+	return c.requestWithHeader(method, path, nil, body, res)
+}
+
+func (c *Client) requestWithHeader(method string, path string, header map[string]string, body interface{}, res interface{}) (*resty.Response, error) {
+	var data []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		data = b
+	}
+
+	authorization, err := c.authorization(method, path, string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req := resty.New().R().
+		SetHeader("Authorization", authorization).
+		SetHeader("Accept", "application/json").
+		SetHeaders(header)
+	if data != nil {
+		req.SetHeader("Content-Type", "application/json").SetBody(data)
+	}
+	resp, err := req.Execute(method, c.BaseUrl+path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Debug(path, c.Logger.Field("status", resp.StatusCode()), c.Logger.Field("response", resp.Body()), c.Logger.Field("mchId", c.MchId))
+
+	if err := decodeError(resp); err != nil {
+		return resp, err
+	}
+
+	if path != "/v3/certificates" {
+		if err := c.VerifyHeader(resp.Header(), resp.Body()); err != nil {
+			return resp, err
+		}
+	}
+
+	if res != nil && len(resp.Body()) > 0 {
+		if err := json.Unmarshal(resp.Body(), res); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
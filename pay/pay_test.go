@@ -0,0 +1,145 @@
+package pay
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	localTime "github.com/go-tron/local-time"
+	"github.com/go-tron/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var testKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+var platformKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+const platformPublicKeyId = "PUB_KEY_ID_0112340000000001"
+
+func writeTestResponse(w http.ResponseWriter, serial string, status int, body string) {
+	timestamp := strconv.FormatInt(localTime.Now().Unix(), 10)
+	nonce := "5K8264ILTKCH16CQ2502SI8ZNMTM67VS"
+	hashed := sha256.Sum256([]byte(timestamp + "\n" + nonce + "\n" + body + "\n"))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, platformKey, crypto.SHA256, hashed[:])
+	w.Header().Set("Wechatpay-Timestamp", timestamp)
+	w.Header().Set("Wechatpay-Nonce", nonce)
+	w.Header().Set("Wechatpay-Signature", base64.StdEncoding.EncodeToString(signature))
+	w.Header().Set("Wechatpay-Serial", serial)
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func testPrivateKey() string {
+	data, _ := x509.MarshalPKCS8PrivateKey(testKey)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}))
+}
+
+func newTestClient(baseUrl string) *Client {
+	return New(&Config{
+		Verifier: &PublicKeyVerifier{
+			PublicKeyId: platformPublicKeyId,
+			PublicKey:   &platformKey.PublicKey,
+		},
+		AppId:      "wx8f3c9c583c35ebd5",
+		MchId:      "1230000109",
+		SerialNo:   "5157F09EFDC096DE15EBE81A47057A7232F1B8E1",
+		PrivateKey: testPrivateKey(),
+		ApiV3Key:   "0123456789abcdef0123456789abcdef",
+		NotifyUrl:  "https://weixin.eioos.com/pay/notify",
+		BaseUrl:    baseUrl,
+		Logger:     logger.NewZap("weixin-pay", "info"),
+	})
+}
+
+func verifyTestSign(message string, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	hashed := sha256.Sum256([]byte(message))
+	return rsa.VerifyPKCS1v15(&testKey.PublicKey, crypto.SHA256, hashed[:], sig)
+}
+
+func TestJsApi(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "WECHATPAY2-SHA256-RSA2048 ") || !strings.Contains(authorization, `mchid="1230000109"`) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"appid":"wx8f3c9c583c35ebd5"`) || !strings.Contains(string(body), `"openid":"oasi95rPit953LHRYfaifGnTuqgs"`) {
+			writeTestResponse(w, platformPublicKeyId, http.StatusBadRequest, `{"code":"PARAM_ERROR","message":"参数错误"}`)
+			return
+		}
+		writeTestResponse(w, platformPublicKeyId, http.StatusOK, `{"prepay_id":"wx201410272009395522657a690389285100"}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	result, err := client.JsApi(&PrepayReq{
+		Description: "description",
+		OutTradeNo:  "1217752501201407033233368018",
+		Amount: &Amount{
+			Total: 100,
+		},
+		Payer: &Payer{
+			OpenId: "oasi95rPit953LHRYfaifGnTuqgs",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Package != "prepay_id=wx201410272009395522657a690389285100" {
+		t.Fatal("package", result.Package)
+	}
+	if err := verifyTestSign(result.AppId+"\n"+result.TimeStamp+"\n"+result.NonceStr+"\n"+result.Package+"\n", result.PaySign); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", result)
+}
+
+func TestPrepayError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeTestResponse(w, platformPublicKeyId, http.StatusBadRequest, `{"code":"PARAM_ERROR","message":"参数错误"}`)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).NativePrepay(&PrepayReq{
+		Description: "description",
+		OutTradeNo:  "1217752501201407033233368018",
+		Amount: &Amount{
+			Total: 100,
+		},
+	})
+	e, ok := err.(*Error)
+	if !ok || e.Code != "PARAM_ERROR" || e.StatusCode != http.StatusBadRequest {
+		t.Fatal("error", err)
+	}
+}
+
+func TestResponseSignature(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeTestResponse(w, "PUB_KEY_ID_0112340000000002", http.StatusOK, `{"code_url":"weixin://wxpay/bizpayurl?pr=p4lpSuKzz"}`)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).NativePrepay(&PrepayReq{
+		Description: "description",
+		OutTradeNo:  "1217752501201407033233368018",
+		Amount: &Amount{
+			Total: 100,
+		},
+	})
+	if err == nil {
+		t.Fatal("unverified response accepted")
+	}
+}
@@ -0,0 +1,113 @@
+package pay
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+type RefundStatus string
+
+const (
+	RefundStatusSuccess    RefundStatus = "SUCCESS"
+	RefundStatusClosed     RefundStatus = "CLOSED"
+	RefundStatusProcessing RefundStatus = "PROCESSING"
+	RefundStatusAbnormal   RefundStatus = "ABNORMAL"
+)
+
+func (s RefundStatus) Final() bool {
+	return s != RefundStatusProcessing
+}
+
+type RefundChannel string
+
+const (
+	RefundChannelOriginal      RefundChannel = "ORIGINAL"
+	RefundChannelBalance       RefundChannel = "BALANCE"
+	RefundChannelOtherBalance  RefundChannel = "OTHER_BALANCE"
+	RefundChannelOtherBankcard RefundChannel = "OTHER_BANKCARD"
+)
+
+type FundsAccount string
+
+const (
+	FundsAccountUnsettled   FundsAccount = "UNSETTLED"
+	FundsAccountAvailable   FundsAccount = "AVAILABLE"
+	FundsAccountUnavailable FundsAccount = "UNAVAILABLE"
+	FundsAccountOperation   FundsAccount = "OPERATION"
+	FundsAccountBasic       FundsAccount = "BASIC"
+)
+
+type RefundReqAmount struct {
+	Refund   int64  `json:"refund"`
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+}
+
+type RefundReq struct {
+	TransactionId string           `json:"transaction_id,omitempty"`
+	OutTradeNo    string           `json:"out_trade_no,omitempty"`
+	OutRefundNo   string           `json:"out_refund_no"`
+	Reason        string           `json:"reason,omitempty"`
+	NotifyUrl     string           `json:"notify_url,omitempty"`
+	FundsAccount  FundsAccount     `json:"funds_account,omitempty"`
+	Amount        *RefundReqAmount `json:"amount"`
+}
+
+type RefundAmount struct {
+	Total            int64  `json:"total"`
+	Refund           int64  `json:"refund"`
+	PayerTotal       int64  `json:"payer_total"`
+	PayerRefund      int64  `json:"payer_refund"`
+	SettlementRefund int64  `json:"settlement_refund"`
+	SettlementTotal  int64  `json:"settlement_total"`
+	DiscountRefund   int64  `json:"discount_refund"`
+	Currency         string `json:"currency"`
+}
+
+type Refund struct {
+	RefundId            string        `json:"refund_id"`
+	OutRefundNo         string        `json:"out_refund_no"`
+	TransactionId       string        `json:"transaction_id"`
+	OutTradeNo          string        `json:"out_trade_no"`
+	Channel             RefundChannel `json:"channel"`
+	UserReceivedAccount string        `json:"user_received_account"`
+	SuccessTime         string        `json:"success_time"`
+	CreateTime          string        `json:"create_time"`
+	Status              RefundStatus  `json:"status"`
+	FundsAccount        FundsAccount  `json:"funds_account"`
+	Amount              *RefundAmount `json:"amount"`
+}
+
+func (c *Client) CreateRefund(params *RefundReq) (*Refund, error) {
+	if params.NotifyUrl == "" {
+		params.NotifyUrl = c.NotifyUrl
+	}
+	if params.Amount != nil && params.Amount.Currency == "" {
+		params.Amount.Currency = "CNY"
+	}
+	var res = &Refund{}
+	if _, err := c.request("POST", "/v3/refund/domestic/refunds", params, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) QueryRefund(outRefundNo string) (*Refund, error) {
+	var res = &Refund{}
+	if _, err := c.request("GET", "/v3/refund/domestic/refunds/"+url.PathEscape(outRefundNo), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) WaitRefundStatus(ctx context.Context, outRefundNo string, interval time.Duration) (refund *Refund, err error) {
+	err = c.poll(ctx, "WaitRefundStatus", interval, func() (bool, error) {
+		refund, err = c.QueryRefund(outRefundNo)
+		if err != nil {
+			return false, err
+		}
+		return refund.Status.Final(), nil
+	})
+	return refund, err
+}
@@ -0,0 +1,87 @@
+package pay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateRefund(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/v3/refund/domestic/refunds" || !strings.Contains(string(body), `"currency":"CNY"`) {
+			writeTestResponse(w, platformPublicKeyId, http.StatusBadRequest, `{"code":"PARAM_ERROR","message":"参数错误"}`)
+			return
+		}
+		writeTestResponse(w, platformPublicKeyId, http.StatusOK, `{"refund_id":"50000000382019052709732678859","out_refund_no":"1217752501201407033233368018","status":"PROCESSING","channel":"ORIGINAL","amount":{"total":100,"refund":100,"currency":"CNY"}}`)
+	}))
+	defer server.Close()
+
+	result, err := newTestClient(server.URL).CreateRefund(&RefundReq{
+		OutTradeNo:  "1217752501201407033233368018",
+		OutRefundNo: "1217752501201407033233368018",
+		Amount: &RefundReqAmount{
+			Refund: 100,
+			Total:  100,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != RefundStatusProcessing || result.Status.Final() || result.Amount.Refund != 100 {
+		t.Fatal("result", result)
+	}
+}
+
+func TestWaitTradeState(t *testing.T) {
+	var queries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/pay/transactions/out-trade-no/1217752501201407033233368018" || r.URL.Query().Get("mchid") != "1230000109" {
+			writeTestResponse(w, platformPublicKeyId, http.StatusNotFound, `{"code":"ORDER_NOT_EXIST","message":"订单不存在"}`)
+			return
+		}
+		queries++
+		if queries == 1 {
+			writeTestResponse(w, platformPublicKeyId, http.StatusInternalServerError, `{"code":"SYSTEM_ERROR","message":"系统错误"}`)
+			return
+		}
+		if queries < 3 {
+			writeTestResponse(w, platformPublicKeyId, http.StatusOK, `{"out_trade_no":"1217752501201407033233368018","trade_state":"USERPAYING"}`)
+			return
+		}
+		writeTestResponse(w, platformPublicKeyId, http.StatusOK, `{"out_trade_no":"1217752501201407033233368018","trade_state":"SUCCESS","transaction_id":"1217752501201407033233368018"}`)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	result, err := newTestClient(server.URL).WaitTradeState(ctx, "1217752501201407033233368018", time.Millisecond*10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TradeState != TradeStateSuccess || queries != 3 {
+		t.Fatal("result", result, queries)
+	}
+}
+
+func TestWaitRefundStatusPermanentError(t *testing.T) {
+	var queries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		writeTestResponse(w, platformPublicKeyId, http.StatusNotFound, `{"code":"RESOURCE_NOT_EXISTS","message":"退款单不存在"}`)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if _, err := newTestClient(server.URL).WaitRefundStatus(ctx, "1217752501201407033233368018", time.Millisecond*10); err == nil || ctx.Err() != nil {
+		t.Fatal("err", err)
+	}
+	if queries != 1 {
+		t.Fatal("queries", queries)
+	}
+}
@@ -0,0 +1,283 @@
+package pay
+
+import (
+	"context"
+	"errors"
+	localTime "github.com/go-tron/local-time"
+	"github.com/go-tron/random"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Amount struct {
+	Total    int64  `json:"total"`
+	Currency string `json:"currency,omitempty"`
+}
+
+type Payer struct {
+	OpenId string `json:"openid"`
+}
+
+type H5Info struct {
+	Type string `json:"type"`
+}
+
+type SceneInfo struct {
+	PayerClientIp string  `json:"payer_client_ip"`
+	DeviceId      string  `json:"device_id,omitempty"`
+	H5Info        *H5Info `json:"h5_info,omitempty"`
+}
+
+type PrepayReq struct {
+	Description string     `json:"description"`
+	OutTradeNo  string     `json:"out_trade_no"`
+	TimeExpire  string     `json:"time_expire,omitempty"`
+	Attach      string     `json:"attach,omitempty"`
+	NotifyUrl   string     `json:"notify_url"`
+	GoodsTag    string     `json:"goods_tag,omitempty"`
+	Amount      *Amount    `json:"amount"`
+	Payer       *Payer     `json:"payer,omitempty"`
+	SceneInfo   *SceneInfo `json:"scene_info,omitempty"`
+}
+
+type PrepayRes struct {
+	PrepayId string `json:"prepay_id"`
+	H5Url    string `json:"h5_url"`
+	CodeUrl  string `json:"code_url"`
+}
+
+func (c *Client) prepay(path string, params *PrepayReq) (*PrepayRes, error) {
+	if params.NotifyUrl == "" {
+		params.NotifyUrl = c.NotifyUrl
+	}
+	body := &struct {
+		AppId string `json:"appid"`
+		MchId string `json:"mchid"`
+		*PrepayReq
+	}{
+		AppId:     c.AppId,
+		MchId:     c.MchId,
+		PrepayReq: params,
+	}
+
+	var res = &PrepayRes{}
+	if _, err := c.request("POST", path, body, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) JsApiPrepay(params *PrepayReq) (string, error) {
+	res, err := c.prepay("/v3/pay/transactions/jsapi", params)
+	if err != nil {
+		return "", err
+	}
+	return res.PrepayId, nil
+}
+
+func (c *Client) AppPrepay(params *PrepayReq) (string, error) {
+	res, err := c.prepay("/v3/pay/transactions/app", params)
+	if err != nil {
+		return "", err
+	}
+	return res.PrepayId, nil
+}
+
+func (c *Client) H5Prepay(params *PrepayReq) (string, error) {
+	res, err := c.prepay("/v3/pay/transactions/h5", params)
+	if err != nil {
+		return "", err
+	}
+	return res.H5Url, nil
+}
+
+func (c *Client) NativePrepay(params *PrepayReq) (string, error) {
+	res, err := c.prepay("/v3/pay/transactions/native", params)
+	if err != nil {
+		return "", err
+	}
+	return res.CodeUrl, nil
+}
+
+type JsApiPayParams struct {
+	AppId     string `json:"appId"`
+	TimeStamp string `json:"timeStamp"`
+	NonceStr  string `json:"nonceStr"`
+	Package   string `json:"package"`
+	SignType  string `json:"signType"`
+	PaySign   string `json:"paySign"`
+}
+
+func (c *Client) GetJsApiPayParams(prepayId string) (*JsApiPayParams, error) {
+	params := &JsApiPayParams{
+		AppId:     c.AppId,
+		TimeStamp: strconv.FormatInt(localTime.Now().Unix(), 10),
+		NonceStr:  random.String(32),
+		Package:   "prepay_id=" + prepayId,
+		SignType:  "RSA",
+	}
+	paySign, err := c.Sign(params.AppId + "\n" + params.TimeStamp + "\n" + params.NonceStr + "\n" + params.Package + "\n")
+	if err != nil {
+		return nil, err
+	}
+	params.PaySign = paySign
+	return params, nil
+}
+
+func (c *Client) JsApi(params *PrepayReq) (*JsApiPayParams, error) {
+	prepayId, err := c.JsApiPrepay(params)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetJsApiPayParams(prepayId)
+}
+
+type AppPayParams struct {
+	AppId     string `json:"appid"`
+	PartnerId string `json:"partnerid"`
+	PrepayId  string `json:"prepayid"`
+	Package   string `json:"package"`
+	NonceStr  string `json:"noncestr"`
+	TimeStamp string `json:"timestamp"`
+	Sign      string `json:"sign"`
+}
+
+func (c *Client) GetAppPayParams(prepayId string) (*AppPayParams, error) {
+	params := &AppPayParams{
+		AppId:     c.AppId,
+		PartnerId: c.MchId,
+		PrepayId:  prepayId,
+		Package:   "Sign=WXPay",
+		NonceStr:  random.String(32),
+		TimeStamp: strconv.FormatInt(localTime.Now().Unix(), 10),
+	}
+	sign, err := c.Sign(params.AppId + "\n" + params.TimeStamp + "\n" + params.NonceStr + "\n" + params.PrepayId + "\n")
+	if err != nil {
+		return nil, err
+	}
+	params.Sign = sign
+	return params, nil
+}
+
+type TradeType string
+
+const (
+	TradeTypeJsApi    TradeType = "JSAPI"
+	TradeTypeNative   TradeType = "NATIVE"
+	TradeTypeApp      TradeType = "APP"
+	TradeTypeMicroPay TradeType = "MICROPAY"
+	TradeTypeMWeb     TradeType = "MWEB"
+	TradeTypeFacePay  TradeType = "FACEPAY"
+)
+
+type TradeState string
+
+const (
+	TradeStateSuccess    TradeState = "SUCCESS"
+	TradeStateRefund     TradeState = "REFUND"
+	TradeStateNotPay     TradeState = "NOTPAY"
+	TradeStateClosed     TradeState = "CLOSED"
+	TradeStateRevoked    TradeState = "REVOKED"
+	TradeStateUserPaying TradeState = "USERPAYING"
+	TradeStatePayError   TradeState = "PAYERROR"
+)
+
+func (s TradeState) Final() bool {
+	return s != TradeStateNotPay && s != TradeStateUserPaying
+}
+
+type TransactionAmount struct {
+	Total         int64  `json:"total"`
+	PayerTotal    int64  `json:"payer_total"`
+	Currency      string `json:"currency"`
+	PayerCurrency string `json:"payer_currency"`
+}
+
+type Transaction struct {
+	AppId          string             `json:"appid"`
+	MchId          string             `json:"mchid"`
+	OutTradeNo     string             `json:"out_trade_no"`
+	TransactionId  string             `json:"transaction_id"`
+	TradeType      TradeType          `json:"trade_type"`
+	TradeState     TradeState         `json:"trade_state"`
+	TradeStateDesc string             `json:"trade_state_desc"`
+	BankType       string             `json:"bank_type"`
+	Attach         string             `json:"attach"`
+	SuccessTime    string             `json:"success_time"`
+	Payer          *Payer             `json:"payer"`
+	Amount         *TransactionAmount `json:"amount"`
+}
+
+func (c *Client) QueryTransactionById(transactionId string) (*Transaction, error) {
+	var res = &Transaction{}
+	if _, err := c.request("GET", "/v3/pay/transactions/id/"+url.PathEscape(transactionId)+"?mchid="+url.QueryEscape(c.MchId), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) QueryTransactionByOutTradeNo(outTradeNo string) (*Transaction, error) {
+	var res = &Transaction{}
+	if _, err := c.request("GET", "/v3/pay/transactions/out-trade-no/"+url.PathEscape(outTradeNo)+"?mchid="+url.QueryEscape(c.MchId), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) CloseTransaction(outTradeNo string) error {
+	_, err := c.request("POST", "/v3/pay/transactions/out-trade-no/"+url.PathEscape(outTradeNo)+"/close", map[string]string{
+		"mchid": c.MchId,
+	}, nil)
+	return err
+}
+
+var retryErrCodes = map[string]bool{
+	"SYSTEM_ERROR":      true,
+	"FREQUENCY_LIMITED": true,
+}
+
+// network errors, 5xx and busy codes are transient, any other *Error is not
+func isRetryError(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return true
+	}
+	return e.StatusCode >= http.StatusInternalServerError || retryErrCodes[e.Code]
+}
+
+func (c *Client) poll(ctx context.Context, name string, interval time.Duration, fn func() (bool, error)) error {
+	if interval <= 0 {
+		interval = time.Second * 5
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		done, err := fn()
+		if err != nil && !isRetryError(err) {
+			return err
+		}
+		if err != nil {
+			c.Logger.Warn(name+" retry", c.Logger.Field("error", err), c.Logger.Field("mchId", c.MchId))
+		} else if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) WaitTradeState(ctx context.Context, outTradeNo string, interval time.Duration) (transaction *Transaction, err error) {
+	err = c.poll(ctx, "WaitTradeState", interval, func() (bool, error) {
+		transaction, err = c.QueryTransactionByOutTradeNo(outTradeNo)
+		if err != nil {
+			return false, err
+		}
+		return transaction.TradeState.Final(), nil
+	})
+	return transaction, err
+}
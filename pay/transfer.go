@@ -0,0 +1,247 @@
+package pay
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type TransferBatchStatus string
+
+const (
+	TransferBatchStatusWaitPay    TransferBatchStatus = "WAIT_PAY"
+	TransferBatchStatusAccepted   TransferBatchStatus = "ACCEPTED"
+	TransferBatchStatusProcessing TransferBatchStatus = "PROCESSING"
+	TransferBatchStatusFinished   TransferBatchStatus = "FINISHED"
+	TransferBatchStatusClosed     TransferBatchStatus = "CLOSED"
+)
+
+func (s TransferBatchStatus) Final() bool {
+	return s == TransferBatchStatusFinished || s == TransferBatchStatusClosed
+}
+
+type TransferDetailStatus string
+
+const (
+	TransferDetailStatusInit       TransferDetailStatus = "INIT"
+	TransferDetailStatusWaitPay    TransferDetailStatus = "WAIT_PAY"
+	TransferDetailStatusProcessing TransferDetailStatus = "PROCESSING"
+	TransferDetailStatusSuccess    TransferDetailStatus = "SUCCESS"
+	TransferDetailStatusFail       TransferDetailStatus = "FAIL"
+)
+
+type TransferDetailReq struct {
+	OutDetailNo    string `json:"out_detail_no"`
+	TransferAmount int64  `json:"transfer_amount"`
+	TransferRemark string `json:"transfer_remark"`
+	OpenId         string `json:"openid"`
+	UserName       string `json:"user_name,omitempty"`
+}
+
+type TransferBatchReq struct {
+	OutBatchNo      string               `json:"out_batch_no"`
+	BatchName       string               `json:"batch_name"`
+	BatchRemark     string               `json:"batch_remark"`
+	TransferSceneId string               `json:"transfer_scene_id,omitempty"`
+	NotifyUrl       string               `json:"notify_url,omitempty"`
+	Details         []*TransferDetailReq `json:"transfer_detail_list"`
+	MaxRetries      int                  `json:"-"`
+	RetryInterval   time.Duration        `json:"-"`
+}
+
+type TransferBatchRes struct {
+	OutBatchNo  string              `json:"out_batch_no"`
+	BatchId     string              `json:"batch_id"`
+	CreateTime  string              `json:"create_time"`
+	BatchStatus TransferBatchStatus `json:"batch_status"`
+}
+
+func (c *Client) TransferBatch(ctx context.Context, params *TransferBatchReq) (*TransferBatchRes, error) {
+	if params.OutBatchNo == "" {
+		return nil, errors.New("outBatchNo 必须设置")
+	}
+	if len(params.Details) == 0 {
+		return nil, errors.New("details 必须设置")
+	}
+	maxRetries := params.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	} else if maxRetries < 0 {
+		maxRetries = 0
+	}
+	retryInterval := params.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = time.Second
+	}
+
+	var header map[string]string
+	var publicKey *rsa.PublicKey
+	var details = make([]*TransferDetailReq, len(params.Details))
+	var totalAmount int64
+	for i, detail := range params.Details {
+		d := *detail
+		if d.UserName != "" {
+			if publicKey == nil {
+				serial, key, err := c.EncryptKey()
+				if err != nil {
+					return nil, err
+				}
+				publicKey = key
+				header = map[string]string{
+					"Wechatpay-Serial": serial,
+				}
+			}
+			var err error
+			if d.UserName, err = EncryptOAEP(publicKey, d.UserName); err != nil {
+				return nil, err
+			}
+		}
+		details[i] = &d
+		totalAmount += d.TransferAmount
+	}
+	body := &struct {
+		AppId       string `json:"appid"`
+		TotalAmount int64  `json:"total_amount"`
+		TotalNum    int    `json:"total_num"`
+		*TransferBatchReq
+		Details []*TransferDetailReq `json:"transfer_detail_list"`
+	}{
+		AppId:            c.AppId,
+		TotalAmount:      totalAmount,
+		TotalNum:         len(details),
+		TransferBatchReq: params,
+		Details:          details,
+	}
+
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(retryInterval * time.Duration(attempt)):
+			}
+		}
+		var res = &TransferBatchRes{}
+		_, err = c.requestWithHeader("POST", "/v3/transfer/batches", header, body, res)
+		if err == nil {
+			return res, nil
+		}
+		var e *Error
+		if errors.As(err, &e) && e.Code == "ALREADY_EXISTS" {
+			break
+		}
+		if !isRetryError(err) {
+			return nil, err
+		}
+		c.Logger.Warn("TransferBatch retry", c.Logger.Field("error", err), c.Logger.Field("outBatchNo", params.OutBatchNo), c.Logger.Field("attempt", attempt), c.Logger.Field("mchId", c.MchId))
+	}
+
+	batch, queryErr := c.QueryTransferBatch(&TransferBatchQueryReq{
+		OutBatchNo: params.OutBatchNo,
+	})
+	if queryErr != nil {
+		return nil, err
+	}
+	// a reused out_batch_no must not report someone else's batch as ours
+	if batch.TransferBatch.TotalAmount != totalAmount || batch.TransferBatch.TotalNum != len(details) {
+		return nil, errors.New(fmt.Sprintf("transfer batch mismatch:%s", params.OutBatchNo))
+	}
+	return &TransferBatchRes{
+		OutBatchNo:  batch.TransferBatch.OutBatchNo,
+		BatchId:     batch.TransferBatch.BatchId,
+		CreateTime:  batch.TransferBatch.CreateTime,
+		BatchStatus: batch.TransferBatch.BatchStatus,
+	}, nil
+}
+
+type TransferBatchQueryReq struct {
+	OutBatchNo      string               `json:"outBatchNo"`
+	NeedQueryDetail bool                 `json:"needQueryDetail"`
+	Offset          int                  `json:"offset"`
+	Limit           int                  `json:"limit"`
+	DetailStatus    TransferDetailStatus `json:"detailStatus"`
+}
+
+type TransferBatch struct {
+	MchId           string              `json:"mchid"`
+	OutBatchNo      string              `json:"out_batch_no"`
+	BatchId         string              `json:"batch_id"`
+	AppId           string              `json:"appid"`
+	BatchStatus     TransferBatchStatus `json:"batch_status"`
+	BatchType       string              `json:"batch_type"`
+	BatchName       string              `json:"batch_name"`
+	BatchRemark     string              `json:"batch_remark"`
+	CloseReason     string              `json:"close_reason"`
+	TotalAmount     int64               `json:"total_amount"`
+	TotalNum        int                 `json:"total_num"`
+	CreateTime      string              `json:"create_time"`
+	UpdateTime      string              `json:"update_time"`
+	SuccessAmount   int64               `json:"success_amount"`
+	SuccessNum      int                 `json:"success_num"`
+	FailAmount      int64               `json:"fail_amount"`
+	FailNum         int                 `json:"fail_num"`
+	TransferSceneId string              `json:"transfer_scene_id"`
+}
+
+type TransferDetailItem struct {
+	DetailId     string               `json:"detail_id"`
+	OutDetailNo  string               `json:"out_detail_no"`
+	DetailStatus TransferDetailStatus `json:"detail_status"`
+}
+
+type TransferBatchQueryRes struct {
+	TransferBatch *TransferBatch        `json:"transfer_batch"`
+	Details       []*TransferDetailItem `json:"transfer_detail_list"`
+}
+
+func (c *Client) QueryTransferBatch(params *TransferBatchQueryReq) (*TransferBatchQueryRes, error) {
+	v := url.Values{}
+	v.Set("need_query_detail", strconv.FormatBool(params.NeedQueryDetail))
+	if params.NeedQueryDetail {
+		limit := params.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		v.Set("offset", strconv.Itoa(params.Offset))
+		v.Set("limit", strconv.Itoa(limit))
+		if params.DetailStatus != "" {
+			v.Set("detail_status", string(params.DetailStatus))
+		}
+	}
+
+	var res = &TransferBatchQueryRes{}
+	if _, err := c.request("GET", "/v3/transfer/batches/out-batch-no/"+url.PathEscape(params.OutBatchNo)+"?"+v.Encode(), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type TransferDetail struct {
+	MchId          string               `json:"mchid"`
+	OutBatchNo     string               `json:"out_batch_no"`
+	BatchId        string               `json:"batch_id"`
+	AppId          string               `json:"appid"`
+	OutDetailNo    string               `json:"out_detail_no"`
+	DetailId       string               `json:"detail_id"`
+	DetailStatus   TransferDetailStatus `json:"detail_status"`
+	TransferAmount int64                `json:"transfer_amount"`
+	TransferRemark string               `json:"transfer_remark"`
+	FailReason     string               `json:"fail_reason"`
+	OpenId         string               `json:"openid"`
+	UserName       string               `json:"user_name"`
+	InitiateTime   string               `json:"initiate_time"`
+	UpdateTime     string               `json:"update_time"`
+}
+
+func (c *Client) QueryTransferDetail(outBatchNo string, outDetailNo string) (*TransferDetail, error) {
+	var res = &TransferDetail{}
+	if _, err := c.request("GET", "/v3/transfer/batches/out-batch-no/"+url.PathEscape(outBatchNo)+"/details/out-detail-no/"+url.PathEscape(outDetailNo), nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,152 @@
+package pay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransferBatch(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/transfer/batches" || r.Header.Get("Wechatpay-Serial") != platformPublicKeyId {
+			writeTestResponse(w, platformPublicKeyId, http.StatusBadRequest, `{"code":"PARAM_ERROR","message":"参数错误"}`)
+			return
+		}
+		attempts++
+		if attempts == 1 {
+			writeTestResponse(w, platformPublicKeyId, http.StatusInternalServerError, `{"code":"SYSTEM_ERROR","message":"系统错误"}`)
+			return
+		}
+
+		data, _ := io.ReadAll(r.Body)
+		var body struct {
+			OutBatchNo  string               `json:"out_batch_no"`
+			TotalAmount int64                `json:"total_amount"`
+			TotalNum    int                  `json:"total_num"`
+			Details     []*TransferDetailReq `json:"transfer_detail_list"`
+		}
+		json.Unmarshal(data, &body)
+		userName, err := DecryptOAEP(platformKey, body.Details[0].UserName)
+		if err != nil || userName != "张三" || body.TotalAmount != 300 || body.TotalNum != 2 {
+			writeTestResponse(w, platformPublicKeyId, http.StatusBadRequest, `{"code":"PARAM_ERROR","message":"参数错误"}`)
+			return
+		}
+		writeTestResponse(w, platformPublicKeyId, http.StatusOK, `{"out_batch_no":"`+body.OutBatchNo+`","batch_id":"1030000071100999991182020050700019480001","create_time":"2015-05-20T13:29:35.120+08:00","batch_status":"ACCEPTED"}`)
+	}))
+	defer server.Close()
+
+	params := &TransferBatchReq{
+		OutBatchNo:    "plfk2020042013",
+		BatchName:     "2019年1月深圳分部报销单",
+		BatchRemark:   "2019年1月深圳分部报销单",
+		RetryInterval: time.Millisecond,
+		Details: []*TransferDetailReq{
+			{
+				OutDetailNo:    "x23zy545Bd5436",
+				TransferAmount: 200,
+				TransferRemark: "2020年4月报销",
+				OpenId:         "o-MYE42l80oelYMDE34nYD456Xoy",
+				UserName:       "张三",
+			},
+			{
+				OutDetailNo:    "x23zy545Bd5437",
+				TransferAmount: 100,
+				TransferRemark: "2020年4月报销",
+				OpenId:         "o-MYE42l80oelYMDE34nYD456Xoy",
+			},
+		},
+	}
+	result, err := newTestClient(server.URL).TransferBatch(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 || result.BatchStatus != TransferBatchStatusAccepted || params.Details[0].UserName != "张三" || params.MaxRetries != 0 {
+		t.Fatal("result", result, attempts)
+	}
+}
+
+func TestTransferBatchExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/transfer/batches":
+			writeTestResponse(w, platformPublicKeyId, http.StatusBadRequest, `{"code":"ALREADY_EXISTS","message":"商家批次单号已存在"}`)
+		case "/v3/transfer/batches/out-batch-no/plfk2020042013":
+			writeTestResponse(w, platformPublicKeyId, http.StatusOK, `{"transfer_batch":{"out_batch_no":"plfk2020042013","batch_id":"1030000071100999991182020050700019480001","batch_status":"FINISHED","total_amount":200,"total_num":1}}`)
+		}
+	}))
+	defer server.Close()
+
+	result, err := newTestClient(server.URL).TransferBatch(context.Background(), &TransferBatchReq{
+		OutBatchNo: "plfk2020042013",
+		Details: []*TransferDetailReq{
+			{
+				OutDetailNo:    "x23zy545Bd5436",
+				TransferAmount: 200,
+				OpenId:         "o-MYE42l80oelYMDE34nYD456Xoy",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BatchId != "1030000071100999991182020050700019480001" || !result.BatchStatus.Final() {
+		t.Fatal("result", result)
+	}
+}
+
+func TestTransferBatchMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/transfer/batches":
+			writeTestResponse(w, platformPublicKeyId, http.StatusBadRequest, `{"code":"ALREADY_EXISTS","message":"商家批次单号已存在"}`)
+		case "/v3/transfer/batches/out-batch-no/plfk2020042013":
+			writeTestResponse(w, platformPublicKeyId, http.StatusOK, `{"transfer_batch":{"out_batch_no":"plfk2020042013","batch_id":"1030000071100999991182020050700019480001","batch_status":"FINISHED","total_amount":500,"total_num":1}}`)
+		}
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).TransferBatch(context.Background(), &TransferBatchReq{
+		OutBatchNo: "plfk2020042013",
+		Details: []*TransferDetailReq{
+			{
+				OutDetailNo:    "x23zy545Bd5436",
+				TransferAmount: 200,
+				OpenId:         "o-MYE42l80oelYMDE34nYD456Xoy",
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("mismatched batch accepted")
+	}
+}
+
+func TestTransferBatchCancel(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		writeTestResponse(w, platformPublicKeyId, http.StatusInternalServerError, `{"code":"SYSTEM_ERROR","message":"系统错误"}`)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, err := newTestClient(server.URL).TransferBatch(ctx, &TransferBatchReq{
+		OutBatchNo:    "plfk2020042013",
+		RetryInterval: time.Hour,
+		Details: []*TransferDetailReq{
+			{
+				OutDetailNo:    "x23zy545Bd5436",
+				TransferAmount: 200,
+				OpenId:         "o-MYE42l80oelYMDE34nYD456Xoy",
+			},
+		},
+	})
+	if err != context.DeadlineExceeded || attempts != 1 {
+		t.Fatal("err", err, attempts)
+	}
+}
@@ -0,0 +1,134 @@
+package v2
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type PayNotify struct {
+	Order
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+}
+
+type RefundNotify struct {
+	AppId   string            `xml:"appid"`
+	MchId   string            `xml:"mch_id"`
+	ReqInfo string            `xml:"req_info"`
+	Info    *RefundNotifyInfo `xml:"-"`
+}
+
+type RefundNotifyInfo struct {
+	TransactionId       string `xml:"transaction_id"`
+	OutTradeNo          string `xml:"out_trade_no"`
+	RefundId            string `xml:"refund_id"`
+	OutRefundNo         string `xml:"out_refund_no"`
+	TotalFee            int64  `xml:"total_fee"`
+	SettlementTotalFee  int64  `xml:"settlement_total_fee"`
+	RefundFee           int64  `xml:"refund_fee"`
+	SettlementRefundFee int64  `xml:"settlement_refund_fee"`
+	RefundStatus        string `xml:"refund_status"`
+	SuccessTime         string `xml:"success_time"`
+	RefundRecvAccout    string `xml:"refund_recv_accout"`
+	RefundAccount       string `xml:"refund_account"`
+	RefundRequestSource string `xml:"refund_request_source"`
+}
+
+func readNotify(r *http.Request) ([]byte, Params, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	params, err := ParseParams(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if params["return_code"] != "SUCCESS" {
+		return nil, nil, &Error{Code: params["return_code"], Msg: params["return_msg"]}
+	}
+	return body, params, nil
+}
+
+func (c *Client) ParsePayNotify(r *http.Request) (*PayNotify, error) {
+	body, params, err := readNotify(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.VerifySign(params); err != nil {
+		return nil, err
+	}
+	var notify = &PayNotify{}
+	if err := xml.Unmarshal(body, notify); err != nil {
+		return nil, err
+	}
+	c.Logger.Debug("ParsePayNotify", c.Logger.Field("notify", body), c.Logger.Field("mchId", c.MchId))
+	return notify, nil
+}
+
+func (c *Client) ParseRefundNotify(r *http.Request) (*RefundNotify, error) {
+	body, _, err := readNotify(r)
+	if err != nil {
+		return nil, err
+	}
+	var notify = &RefundNotify{}
+	if err := xml.Unmarshal(body, notify); err != nil {
+		return nil, err
+	}
+	data, err := DecryptReqInfo(c.ApiKey, notify.ReqInfo)
+	if err != nil {
+		return nil, err
+	}
+	var info = &RefundNotifyInfo{}
+	if err := xml.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+	notify.Info = info
+	c.Logger.Debug("ParseRefundNotify", c.Logger.Field("info", data), c.Logger.Field("mchId", c.MchId))
+	return notify, nil
+}
+
+func DecryptReqInfo(apiKey string, reqInfo string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(reqInfo)
+	if err != nil {
+		return nil, err
+	}
+	hash := md5.Sum([]byte(apiKey))
+	block, err := aes.NewCipher([]byte(hex.EncodeToString(hash[:])))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("req_info invalid")
+	}
+	plain := make([]byte, len(data))
+	for i := 0; i < len(data); i += block.BlockSize() {
+		block.Decrypt(plain[i:i+block.BlockSize()], data[i:i+block.BlockSize()])
+	}
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > block.BlockSize() || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("req_info padding invalid")
+	}
+	return plain[:len(plain)-padding], nil
+}
+
+func WriteNotifyResponse(w http.ResponseWriter, err error) {
+	params := Params{
+		"return_code": "SUCCESS",
+		"return_msg":  "OK",
+	}
+	if err != nil {
+		params["return_code"] = "FAIL"
+		params["return_msg"] = err.Error()
+	}
+	data, _ := xml.Marshal(params)
+	w.Header().Set("Content-Type", "text/xml")
+	w.Write(data)
+}
@@ -0,0 +1,208 @@
+package v2
+
+import (
+	localTime "github.com/go-tron/local-time"
+	"github.com/go-tron/random"
+	"strconv"
+)
+
+type TradeType string
+
+const (
+	TradeTypeJsApi  TradeType = "JSAPI"
+	TradeTypeNative TradeType = "NATIVE"
+	TradeTypeApp    TradeType = "APP"
+	TradeTypeMWeb   TradeType = "MWEB"
+)
+
+type UnifiedOrderReq struct {
+	DeviceInfo     string    `xml:"device_info"`
+	Body           string    `xml:"body"`
+	Detail         string    `xml:"detail"`
+	Attach         string    `xml:"attach"`
+	OutTradeNo     string    `xml:"out_trade_no"`
+	FeeType        string    `xml:"fee_type"`
+	TotalFee       int64     `xml:"total_fee"`
+	SpbillCreateIp string    `xml:"spbill_create_ip"`
+	TimeStart      string    `xml:"time_start"`
+	TimeExpire     string    `xml:"time_expire"`
+	GoodsTag       string    `xml:"goods_tag"`
+	NotifyUrl      string    `xml:"notify_url"`
+	TradeType      TradeType `xml:"trade_type"`
+	ProductId      string    `xml:"product_id"`
+	LimitPay       string    `xml:"limit_pay"`
+	OpenId         string    `xml:"openid"`
+	SceneInfo      string    `xml:"scene_info"`
+}
+
+type UnifiedOrderRes struct {
+	TradeType TradeType `xml:"trade_type"`
+	PrepayId  string    `xml:"prepay_id"`
+	CodeUrl   string    `xml:"code_url"`
+	MwebUrl   string    `xml:"mweb_url"`
+}
+
+func (c *Client) UnifiedOrder(params *UnifiedOrderReq) (*UnifiedOrderRes, error) {
+	if params.NotifyUrl == "" {
+		params.NotifyUrl = c.NotifyUrl
+	}
+	p := toParams(params)
+	p["trade_type"] = string(params.TradeType)
+
+	var res = &UnifiedOrderRes{}
+	if err := c.request("UnifiedOrder", "/pay/unifiedorder", p, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type JsApiPayParams struct {
+	AppId     string `json:"appId"`
+	TimeStamp string `json:"timeStamp"`
+	NonceStr  string `json:"nonceStr"`
+	Package   string `json:"package"`
+	SignType  string `json:"signType"`
+	PaySign   string `json:"paySign"`
+}
+
+func (c *Client) GetJsApiPayParams(prepayId string) (*JsApiPayParams, error) {
+	params := &JsApiPayParams{
+		AppId:     c.AppId,
+		TimeStamp: strconv.FormatInt(localTime.Now().Unix(), 10),
+		NonceStr:  random.String(32),
+		Package:   "prepay_id=" + prepayId,
+		SignType:  string(c.SignType),
+	}
+	paySign, err := Sign(Params{
+		"appId":     params.AppId,
+		"timeStamp": params.TimeStamp,
+		"nonceStr":  params.NonceStr,
+		"package":   params.Package,
+		"signType":  params.SignType,
+	}, c.SignType, c.ApiKey)
+	if err != nil {
+		return nil, err
+	}
+	params.PaySign = paySign
+	return params, nil
+}
+
+type TradeState string
+
+const (
+	TradeStateSuccess    TradeState = "SUCCESS"
+	TradeStateRefund     TradeState = "REFUND"
+	TradeStateNotPay     TradeState = "NOTPAY"
+	TradeStateClosed     TradeState = "CLOSED"
+	TradeStateRevoked    TradeState = "REVOKED"
+	TradeStateUserPaying TradeState = "USERPAYING"
+	TradeStatePayError   TradeState = "PAYERROR"
+)
+
+type Order struct {
+	AppId          string     `xml:"appid"`
+	MchId          string     `xml:"mch_id"`
+	DeviceInfo     string     `xml:"device_info"`
+	OpenId         string     `xml:"openid"`
+	IsSubscribe    string     `xml:"is_subscribe"`
+	TradeType      TradeType  `xml:"trade_type"`
+	TradeState     TradeState `xml:"trade_state"`
+	BankType       string     `xml:"bank_type"`
+	TotalFee       int64      `xml:"total_fee"`
+	FeeType        string     `xml:"fee_type"`
+	CashFee        int64      `xml:"cash_fee"`
+	CashFeeType    string     `xml:"cash_fee_type"`
+	CouponFee      int64      `xml:"coupon_fee"`
+	TransactionId  string     `xml:"transaction_id"`
+	OutTradeNo     string     `xml:"out_trade_no"`
+	Attach         string     `xml:"attach"`
+	TimeEnd        string     `xml:"time_end"`
+	TradeStateDesc string     `xml:"trade_state_desc"`
+}
+
+func (c *Client) OrderQuery(transactionId string, outTradeNo string) (*Order, error) {
+	var res = &Order{}
+	if err := c.request("OrderQuery", "/pay/orderquery", Params{
+		"transaction_id": transactionId,
+		"out_trade_no":   outTradeNo,
+	}, nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) CloseOrder(outTradeNo string) error {
+	return c.request("CloseOrder", "/pay/closeorder", Params{
+		"out_trade_no": outTradeNo,
+	}, nil, nil)
+}
+
+type RefundReq struct {
+	TransactionId string `xml:"transaction_id"`
+	OutTradeNo    string `xml:"out_trade_no"`
+	OutRefundNo   string `xml:"out_refund_no"`
+	TotalFee      int64  `xml:"total_fee"`
+	RefundFee     int64  `xml:"refund_fee"`
+	RefundFeeType string `xml:"refund_fee_type"`
+	RefundDesc    string `xml:"refund_desc"`
+	RefundAccount string `xml:"refund_account"`
+	NotifyUrl     string `xml:"notify_url"`
+}
+
+type RefundRes struct {
+	TransactionId string `xml:"transaction_id"`
+	OutTradeNo    string `xml:"out_trade_no"`
+	OutRefundNo   string `xml:"out_refund_no"`
+	RefundId      string `xml:"refund_id"`
+	RefundFee     int64  `xml:"refund_fee"`
+	TotalFee      int64  `xml:"total_fee"`
+	CashFee       int64  `xml:"cash_fee"`
+	CashRefundFee int64  `xml:"cash_refund_fee"`
+}
+
+func (c *Client) Refund(params *RefundReq) (*RefundRes, error) {
+	if params.NotifyUrl == "" {
+		params.NotifyUrl = c.NotifyUrl
+	}
+	var res = &RefundRes{}
+	if err := c.request("Refund", "/secapi/pay/refund", toParams(params), &requestOption{tls: true}, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type RedpackReq struct {
+	MchBillNo   string `xml:"mch_billno"`
+	SendName    string `xml:"send_name"`
+	ReOpenId    string `xml:"re_openid"`
+	TotalAmount int64  `xml:"total_amount"`
+	TotalNum    int    `xml:"total_num"`
+	Wishing     string `xml:"wishing"`
+	ClientIp    string `xml:"client_ip"`
+	ActName     string `xml:"act_name"`
+	Remark      string `xml:"remark"`
+	SceneId     string `xml:"scene_id"`
+	RiskInfo    string `xml:"risk_info"`
+}
+
+type RedpackRes struct {
+	MchBillNo   string `xml:"mch_billno"`
+	ReOpenId    string `xml:"re_openid"`
+	TotalAmount int64  `xml:"total_amount"`
+	SendListId  string `xml:"send_listid"`
+}
+
+func (c *Client) SendRedpack(params *RedpackReq) (*RedpackRes, error) {
+	if params.TotalNum <= 0 {
+		params.TotalNum = 1
+	}
+	var res = &RedpackRes{}
+	if err := c.request("SendRedpack", "/mmpaymkttransfers/sendredpack", toParams(params), &requestOption{
+		tls:      true,
+		appIdKey: "wxappid",
+		signType: SignTypeMD5,
+	}, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,333 @@
+package v2
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/go-tron/config"
+	"github.com/go-tron/logger"
+	"github.com/go-tron/random"
+	"golang.org/x/crypto/pkcs12"
+	"hash"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const BaseUrl = "https://api.mch.weixin.qq.com"
+
+type SignType string
+
+const (
+	SignTypeMD5        SignType = "MD5"
+	SignTypeHmacSha256 SignType = "HMAC-SHA256"
+)
+
+func NewWithConfig(c *config.Config) *Client {
+	conf := &Config{
+		AppId:     c.GetString("weixin.appId"),
+		MchId:     c.GetString("weixin.pay.mchId"),
+		ApiKey:    c.GetString("weixin.pay.apiKey"),
+		SignType:  SignType(c.GetString("weixin.pay.signType")),
+		NotifyUrl: c.GetString("weixin.pay.notifyUrl"),
+		Logger:    logger.NewZapWithConfig(c, "weixin-pay-v2", "info"),
+	}
+	if path := c.GetString("weixin.pay.certificatePath"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		conf.Certificate = data
+	}
+	return New(conf)
+}
+
+func New(c *Config) *Client {
+
+	if c == nil {
+		panic("config 必须设置")
+	}
+	if c.AppId == "" {
+		panic("AppId 必须设置")
+	}
+	if c.MchId == "" {
+		panic("MchId 必须设置")
+	}
+	if c.ApiKey == "" {
+		panic("ApiKey 必须设置")
+	}
+	if c.Logger == nil {
+		panic("Logger 必须设置")
+	}
+	if c.SignType == "" {
+		c.SignType = SignTypeMD5
+	}
+	if c.BaseUrl == "" {
+		c.BaseUrl = BaseUrl
+	}
+
+	client := &Client{
+		Config: c,
+	}
+	if c.Certificate != nil {
+		password := c.CertificatePassword
+		if password == "" {
+			password = c.MchId
+		}
+		certificate, err := LoadPkcs12(c.Certificate, password)
+		if err != nil {
+			panic(err)
+		}
+		client.certificate = &certificate
+	}
+	return client
+}
+
+type Config struct {
+	AppId               string        `json:"appId"`
+	MchId               string        `json:"mchId"`
+	ApiKey              string        `json:"apiKey"`
+	SignType            SignType      `json:"signType"`
+	NotifyUrl           string        `json:"notifyUrl"`
+	BaseUrl             string        `json:"baseUrl"`
+	Certificate         []byte        `json:"-"`
+	CertificatePassword string        `json:"-"`
+	Logger              logger.Logger `json:"logger"`
+}
+
+type Client struct {
+	*Config
+	certificate *tls.Certificate
+}
+
+type Error struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("(%s)%s", e.Code, e.Msg)
+}
+
+func LoadPkcs12(data []byte, password string) (tls.Certificate, error) {
+	privateKey, certificate, err := pkcs12.Decode(data, password)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{certificate.Raw},
+		PrivateKey:  privateKey,
+		Leaf:        certificate,
+	}, nil
+}
+
+type Params map[string]string
+
+func (p Params) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	var keys []string
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	start = xml.StartElement{Name: xml.Name{Local: "xml"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := e.EncodeElement(struct {
+			Value string `xml:",cdata"`
+		}{p[k]}, xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (p Params) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			p[t.Name.Local] = value
+		case xml.EndElement:
+			if t.Name == start.Name {
+				return nil
+			}
+		}
+	}
+}
+
+func ParseParams(data []byte) (Params, error) {
+	var p = Params{}
+	if err := xml.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func Sign(params Params, signType SignType, apiKey string) (string, error) {
+	var keys []string
+	for k, v := range params {
+		if k == "sign" || v == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		buf.WriteString(k + "=" + params[k] + "&")
+	}
+	buf.WriteString("key=" + apiKey)
+
+	var h hash.Hash
+	switch signType {
+	case SignTypeMD5, "":
+		h = md5.New()
+	case SignTypeHmacSha256:
+		h = hmac.New(sha256.New, []byte(apiKey))
+	default:
+		return "", errors.New("sign type not supported:" + string(signType))
+	}
+	h.Write(buf.Bytes())
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil))), nil
+}
+
+func (c *Client) VerifySign(params Params) error {
+	signType := SignType(params["sign_type"])
+	if signType == "" {
+		signType = c.SignType
+	}
+	return c.verifySign(params, signType)
+}
+
+func (c *Client) verifySign(params Params, signType SignType) error {
+	sign, err := Sign(params, signType, c.ApiKey)
+	if err != nil {
+		return err
+	}
+	if params["sign"] == "" || !hmac.Equal([]byte(sign), []byte(params["sign"])) {
+		return errors.New("sign invalid")
+	}
+	return nil
+}
+
+func toParams(v interface{}) Params {
+	var p = Params{}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name, _, _ := strings.Cut(rt.Field(i).Tag.Get("xml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		field := rv.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			if field.String() != "" {
+				p[name] = field.String()
+			}
+		case reflect.Int, reflect.Int64:
+			if field.Int() != 0 {
+				p[name] = strconv.FormatInt(field.Int(), 10)
+			}
+		}
+	}
+	return p
+}
+
+type requestOption struct {
+	tls      bool
+	appIdKey string
+	signType SignType
+}
+
+func (c *Client) request(name string, path string, params Params, option *requestOption, res interface{}) error {
+	if option == nil {
+		option = &requestOption{}
+	}
+	appIdKey := option.appIdKey
+	if appIdKey == "" {
+		appIdKey = "appid"
+	}
+	signType := option.signType
+	if signType == "" {
+		signType = c.SignType
+	}
+
+	params[appIdKey] = c.AppId
+	params["mch_id"] = c.MchId
+	params["nonce_str"] = random.String(32)
+	if signType != SignTypeMD5 {
+		params["sign_type"] = string(signType)
+	}
+	sign, err := Sign(params, signType, c.ApiKey)
+	if err != nil {
+		return err
+	}
+	params["sign"] = sign
+
+	body, err := xml.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	client := resty.New()
+	if option.tls {
+		if c.certificate == nil {
+			return errors.New("Certificate 必须设置")
+		}
+		client.SetCertificates(*c.certificate)
+	}
+	resp, err := client.R().
+		SetHeader("Content-Type", "text/xml").
+		SetBody(body).
+		Post(c.BaseUrl + path)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Debug(name, c.Logger.Field("response", resp.Body()), c.Logger.Field("mchId", c.MchId))
+
+	result, err := ParseParams(resp.Body())
+	if err != nil {
+		return err
+	}
+	if result["return_code"] != "SUCCESS" {
+		return &Error{Code: result["return_code"], Msg: result["return_msg"]}
+	}
+	if result["sign"] != "" {
+		if err := c.verifySign(result, signType); err != nil {
+			return err
+		}
+	}
+	if result["result_code"] != "" && result["result_code"] != "SUCCESS" {
+		return &Error{Code: result["err_code"], Msg: result["err_code_des"]}
+	}
+	if res == nil {
+		return nil
+	}
+	return xml.Unmarshal(resp.Body(), res)
+}
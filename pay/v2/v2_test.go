@@ -0,0 +1,128 @@
+package v2
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"github.com/go-tron/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestClient(baseUrl string, signType SignType) *Client {
+	return New(&Config{
+		AppId:     "wxd930ea5d5a258f4f",
+		MchId:     "10000100",
+		ApiKey:    "192006250b4c09247ec02edce69f6a2d",
+		SignType:  signType,
+		NotifyUrl: "https://weixin.eioos.com/pay/notify",
+		BaseUrl:   baseUrl,
+		Logger:    logger.NewZap("weixin-pay-v2", "info"),
+	})
+}
+
+func TestSign(t *testing.T) {
+	params := Params{
+		"appid":       "wxd930ea5d5a258f4f",
+		"mch_id":      "10000100",
+		"device_info": "1000",
+		"body":        "test",
+		"nonce_str":   "ibuaiVcKdpRxkhJA",
+	}
+	sign, err := Sign(params, SignTypeMD5, "192006250b4c09247ec02edce69f6a2d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sign != "9A0A8659F005D6984697E2CA0A9CF3B7" {
+		t.Fatal("sign", sign)
+	}
+	sign, err = Sign(params, SignTypeHmacSha256, "192006250b4c09247ec02edce69f6a2d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sign != "6A9AE1657590FD6257D693A078E1C3E4BB6BA4DC30B23E0EE2496E54170DACD6" {
+		t.Fatal("sign", sign)
+	}
+}
+
+func TestUnifiedOrder(t *testing.T) {
+	client := newTestClient("", SignTypeHmacSha256)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		params, err := ParseParams(body)
+		if err != nil || client.VerifySign(params) != nil || params["total_fee"] != "100" || params["notify_url"] != client.NotifyUrl {
+			w.Write([]byte(`<xml><return_code><![CDATA[FAIL]]></return_code><return_msg><![CDATA[签名错误]]></return_msg></xml>`))
+			return
+		}
+		res := Params{
+			"return_code": "SUCCESS",
+			"return_msg":  "OK",
+			"appid":       params["appid"],
+			"mch_id":      params["mch_id"],
+			"nonce_str":   "IITRi8Iabbblz1Jc",
+			"result_code": "SUCCESS",
+			"prepay_id":   "wx201411101639507cbf6ffd8b0779950874",
+			"trade_type":  "JSAPI",
+		}
+		res["sign"], _ = Sign(res, SignTypeHmacSha256, client.ApiKey)
+		data, _ := xml.Marshal(res)
+		w.Write(data)
+	}))
+	defer server.Close()
+	client.BaseUrl = server.URL
+
+	result, err := client.UnifiedOrder(&UnifiedOrderReq{
+		Body:           "test",
+		OutTradeNo:     "1217752501201407033233368018",
+		TotalFee:       100,
+		SpbillCreateIp: "123.12.12.123",
+		TradeType:      TradeTypeJsApi,
+		OpenId:         "oUpF8uMuAJO_M2pxb1Q9zNjWeS6o",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PrepayId != "wx201411101639507cbf6ffd8b0779950874" {
+		t.Fatal("result", result)
+	}
+}
+
+func TestRefundWithoutCertificate(t *testing.T) {
+	_, err := newTestClient("", SignTypeMD5).Refund(&RefundReq{
+		OutTradeNo:  "1217752501201407033233368018",
+		OutRefundNo: "1217752501201407033233368018",
+		TotalFee:    100,
+		RefundFee:   100,
+	})
+	if err == nil {
+		t.Fatal("refund without certificate accepted")
+	}
+}
+
+func TestParseRefundNotify(t *testing.T) {
+	client := newTestClient("", SignTypeMD5)
+	info := []byte(`<root><out_refund_no><![CDATA[131811191610442717309]]></out_refund_no><refund_fee><![CDATA[3960]]></refund_fee><refund_status><![CDATA[SUCCESS]]></refund_status></root>`)
+
+	hash := md5.Sum([]byte(client.ApiKey))
+	block, _ := aes.NewCipher([]byte(hex.EncodeToString(hash[:])))
+	padding := block.BlockSize() - len(info)%block.BlockSize()
+	plain := append(info, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(plain))
+	for i := 0; i < len(plain); i += block.BlockSize() {
+		block.Encrypt(ciphertext[i:i+block.BlockSize()], plain[i:i+block.BlockSize()])
+	}
+
+	body := `<xml><return_code>SUCCESS</return_code><appid>wxd930ea5d5a258f4f</appid><mch_id>10000100</mch_id><req_info>` + base64.StdEncoding.EncodeToString(ciphertext) + `</req_info></xml>`
+	notify, err := client.ParseRefundNotify(httptest.NewRequest("POST", "/pay/refund/notify", bytes.NewReader([]byte(body))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notify.Info.OutRefundNo != "131811191610442717309" || notify.Info.RefundFee != 3960 {
+		t.Fatal("notify", notify.Info)
+	}
+}
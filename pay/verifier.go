@@ -0,0 +1,147 @@
+package pay
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type Verifier interface {
+	Verify(serial string, message string, signature string) error
+}
+
+type Encryptor interface {
+	EncryptKey() (string, *rsa.PublicKey, error)
+}
+
+func verifySignature(publicKey *rsa.PublicKey, message string, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	hashed := sha256.Sum256([]byte(message))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], sig); err != nil {
+		return errors.New("signature invalid")
+	}
+	return nil
+}
+
+func ParsePublicKey(publicKey string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.New("publicKey invalid")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("publicKey is not rsa")
+	}
+	return rsaKey, nil
+}
+
+func ParseCertificate(certificate string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil {
+		return nil, errors.New("certificate invalid")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func NewPublicKeyVerifier(publicKeyId string, publicKey string) (*PublicKeyVerifier, error) {
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKeyVerifier{
+		PublicKeyId: publicKeyId,
+		PublicKey:   key,
+	}, nil
+}
+
+type PublicKeyVerifier struct {
+	PublicKeyId string
+	PublicKey   *rsa.PublicKey
+}
+
+func (v *PublicKeyVerifier) Verify(serial string, message string, signature string) error {
+	if serial != v.PublicKeyId {
+		return errors.New("public key id mismatch:" + serial)
+	}
+	return verifySignature(v.PublicKey, message, signature)
+}
+
+func (v *PublicKeyVerifier) EncryptKey() (string, *rsa.PublicKey, error) {
+	return v.PublicKeyId, v.PublicKey, nil
+}
+
+func NewCertificateVerifier(certificates ...*x509.Certificate) *CertificateVerifier {
+	v := &CertificateVerifier{
+		certificates: make(map[string]*x509.Certificate),
+	}
+	v.SetCertificates(certificates...)
+	return v
+}
+
+type CertificateVerifier struct {
+	lock         sync.RWMutex
+	certificates map[string]*x509.Certificate
+}
+
+func (v *CertificateVerifier) SetCertificates(certificates ...*x509.Certificate) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	for _, certificate := range certificates {
+		v.certificates[certificateSerial(certificate)] = certificate
+	}
+}
+
+func (v *CertificateVerifier) Certificate(serial string) (*x509.Certificate, bool) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	certificate, ok := v.certificates[serial]
+	return certificate, ok
+}
+
+func (v *CertificateVerifier) Verify(serial string, message string, signature string) error {
+	certificate, ok := v.Certificate(serial)
+	if !ok {
+		return errors.New("certificate not found:" + serial)
+	}
+	publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("certificate public key is not rsa")
+	}
+	return verifySignature(publicKey, message, signature)
+}
+
+func (v *CertificateVerifier) EncryptKey() (string, *rsa.PublicKey, error) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	var latest *x509.Certificate
+	for _, certificate := range v.certificates {
+		if latest == nil || certificate.NotAfter.After(latest.NotAfter) {
+			latest = certificate
+		}
+	}
+	if latest == nil {
+		return "", nil, errors.New("certificates 不存在")
+	}
+	publicKey, ok := latest.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return "", nil, errors.New("certificate public key is not rsa")
+	}
+	return certificateSerial(latest), publicKey, nil
+}
+
+func certificateSerial(certificate *x509.Certificate) string {
+	return fmt.Sprintf("%X", certificate.SerialNumber)
+}
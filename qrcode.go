@@ -0,0 +1,209 @@
+package weixin
+
+import (
+	"context"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"github.com/go-tron/redis"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const QrCodeScenePrefix = "wx-qrcode-scene:"
+
+type QrCodeActionName string
+
+const (
+	QrCodeActionScene         QrCodeActionName = "QR_SCENE"
+	QrCodeActionStrScene      QrCodeActionName = "QR_STR_SCENE"
+	QrCodeActionLimitScene    QrCodeActionName = "QR_LIMIT_SCENE"
+	QrCodeActionLimitStrScene QrCodeActionName = "QR_LIMIT_STR_SCENE"
+)
+
+type QrCodeReq struct {
+	Permanent     bool   `json:"permanent"`
+	ExpireSeconds int    `json:"expireSeconds"`
+	SceneId       int    `json:"sceneId"`
+	SceneStr      string `json:"sceneStr"`
+}
+
+type QrCodeRes struct {
+	ErrCode       int    `json:"errcode"`
+	ErrMsg        string `json:"errmsg"`
+	Ticket        string `json:"ticket"`
+	ExpireSeconds int    `json:"expire_seconds"`
+	Url           string `json:"url"`
+}
+
+func (r *QrCodeRes) ShowUrl() string {
+	return ShowQrCodeUrl(r.Ticket)
+}
+
+func (wx *Weixin) CreateQrCode(params *QrCodeReq) (*QrCodeRes, error) {
+	var actionName QrCodeActionName
+	var scene = map[string]interface{}{}
+	if params.SceneStr != "" {
+		if len(params.SceneStr) > 64 {
+			return nil, errors.New("sceneStr 长度限制为1到64")
+		}
+		scene["scene_str"] = params.SceneStr
+		actionName = QrCodeActionStrScene
+		if params.Permanent {
+			actionName = QrCodeActionLimitStrScene
+		}
+	} else {
+		if params.SceneId <= 0 {
+			return nil, errors.New("sceneId 必须设置")
+		}
+		if params.Permanent && params.SceneId > 100000 {
+			return nil, errors.New("永久二维码 sceneId 取值范围1到100000")
+		}
+		scene["scene_id"] = params.SceneId
+		actionName = QrCodeActionScene
+		if params.Permanent {
+			actionName = QrCodeActionLimitScene
+		}
+	}
+
+	body := map[string]interface{}{
+		"action_name": actionName,
+		"action_info": map[string]interface{}{
+			"scene": scene,
+		},
+	}
+	if !params.Permanent && params.ExpireSeconds > 0 {
+		body["expire_seconds"] = params.ExpireSeconds
+	}
+
+	var res = &QrCodeRes{}
+	if err := wx.post("CreateQrCode", "https://api.weixin.qq.com/cgi-bin/qrcode/create", body, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func ShowQrCodeUrl(ticket string) string {
+	return "https://mp.weixin.qq.com/cgi-bin/showqrcode?ticket=" + url.QueryEscape(ticket)
+}
+
+func (wx *Weixin) ShowQrCode(ticket string) ([]byte, error) {
+	resp, err := resty.New().R().
+		Get(ShowQrCodeUrl(ticket))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(resp.Header().Get("Content-Type"), "image/") {
+		if err := decodeRes("ShowQrCode", resp.Body(), nil); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("ShowQrCode")
+	}
+	return resp.Body(), nil
+}
+
+type QrCodeScan struct {
+	OpenId    string `json:"openId"`
+	Scene     string `json:"scene"`
+	Ticket    string `json:"ticket"`
+	Subscribe bool   `json:"subscribe"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+func (m *Message) QrCodeScan() *QrCodeScan {
+	if m.MsgType != MsgTypeEvent || m.Ticket == "" {
+		return nil
+	}
+	switch m.Event {
+	case EventSubscribe:
+		return &QrCodeScan{
+			OpenId:    m.FromUserName,
+			Scene:     strings.TrimPrefix(m.EventKey, "qrscene_"),
+			Ticket:    m.Ticket,
+			Subscribe: true,
+			CreatedAt: m.CreateTime,
+		}
+	case EventScan:
+		return &QrCodeScan{
+			OpenId:    m.FromUserName,
+			Scene:     m.EventKey,
+			Ticket:    m.Ticket,
+			CreatedAt: m.CreateTime,
+		}
+	}
+	return nil
+}
+
+type QrCodeSceneStore interface {
+	SaveQrCodeScan(scan *QrCodeScan) error
+	GetQrCodeScene(openId string) (string, error)
+	GetQrCodeSceneCount(scene string) (subscribe int64, scan int64, err error)
+}
+
+func NewRedisQrCodeSceneStore(redis *redis.Redis, appId string) QrCodeSceneStore {
+	return &RedisQrCodeSceneStore{
+		Redis: redis,
+		AppId: appId,
+	}
+}
+
+type RedisQrCodeSceneStore struct {
+	Redis *redis.Redis
+	AppId string
+}
+
+func (s *RedisQrCodeSceneStore) SaveQrCodeScan(scan *QrCodeScan) error {
+	field := "scan"
+	if scan.Subscribe {
+		field = "subscribe"
+	}
+	pipe := s.Redis.TxPipeline()
+	if scan.Subscribe {
+		pipe.SetNX(context.Background(), QrCodeScenePrefix+s.AppId+":openid:"+scan.OpenId, scan.Scene, 0)
+	}
+	pipe.HIncrBy(context.Background(), QrCodeScenePrefix+s.AppId+":count:"+scan.Scene, field, 1)
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+func (s *RedisQrCodeSceneStore) GetQrCodeScene(openId string) (string, error) {
+	scene, err := s.Redis.Get(context.Background(), QrCodeScenePrefix+s.AppId+":openid:"+openId).Result()
+	if err == redis.Nil {
+		return "", errors.New("qrcode scene not found")
+	}
+	return scene, err
+}
+
+func (s *RedisQrCodeSceneStore) GetQrCodeSceneCount(scene string) (int64, int64, error) {
+	values, err := s.Redis.HGetAll(context.Background(), QrCodeScenePrefix+s.AppId+":count:"+scene).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	subscribe, _ := strconv.ParseInt(values["subscribe"], 10, 64)
+	scan, _ := strconv.ParseInt(values["scan"], 10, 64)
+	return subscribe, scan, nil
+}
+
+func (wx *Weixin) qrCodeSceneStore() QrCodeSceneStore {
+	if wx.QrCodeSceneStore != nil {
+		return wx.QrCodeSceneStore
+	}
+	return NewRedisQrCodeSceneStore(wx.Redis, wx.AppId)
+}
+
+func (wx *Weixin) RecordQrCodeScan(msg *Message) error {
+	scan := msg.QrCodeScan()
+	if scan == nil {
+		return nil
+	}
+	wx.Logger.Debug("RecordQrCodeScan", wx.Logger.Field("openId", scan.OpenId), wx.Logger.Field("scene", scan.Scene), wx.Logger.Field("appId", wx.AppId))
+	return wx.qrCodeSceneStore().SaveQrCodeScan(scan)
+}
+
+func (wx *Weixin) GetQrCodeScene(openId string) (string, error) {
+	return wx.qrCodeSceneStore().GetQrCodeScene(openId)
+}
+
+func (wx *Weixin) GetQrCodeSceneCount(scene string) (int64, int64, error) {
+	return wx.qrCodeSceneStore().GetQrCodeSceneCount(scene)
+}
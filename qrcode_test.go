@@ -0,0 +1,34 @@
+package weixin
+
+import (
+	"testing"
+)
+
+func TestCreateQrCode(t *testing.T) {
+	result, err := account.CreateQrCode(&QrCodeReq{
+		ExpireSeconds: 604800,
+		SceneStr:      "store-001",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", result.ShowUrl())
+}
+
+func TestRecordQrCodeScan(t *testing.T) {
+	msg, err := ParseMessage([]byte(`<xml><ToUserName><![CDATA[toUser]]></ToUserName><FromUserName><![CDATA[oasi95rPit953LHRYfaifGnTuqgs]]></FromUserName><CreateTime>123456789</CreateTime><MsgType><![CDATA[event]]></MsgType><Event><![CDATA[subscribe]]></Event><EventKey><![CDATA[qrscene_store-001]]></EventKey><Ticket><![CDATA[TICKET]]></Ticket></xml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := account.HandleMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	scene, err := account.GetQrCodeScene("oasi95rPit953LHRYfaifGnTuqgs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scene != "store-001" {
+		t.Fatal("scene", scene)
+	}
+	t.Log("result", scene)
+}
@@ -0,0 +1,79 @@
+package weixin
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+)
+
+type Error struct {
+	Code int    `json:"errcode"`
+	Msg  string `json:"errmsg"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("(%d)%s", e.Code, e.Msg)
+}
+
+type errorRes struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func decodeRes(name string, body []byte, res interface{}) error {
+	var e = &errorRes{}
+	if err := json.Unmarshal(body, e); err != nil {
+		return err
+	}
+	if e.ErrCode != 0 {
+		if e.ErrMsg != "" {
+			return &Error{Code: e.ErrCode, Msg: e.ErrMsg}
+		} else {
+			return &Error{Code: e.ErrCode, Msg: name}
+		}
+	}
+	if res == nil {
+		return nil
+	}
+	return json.Unmarshal(body, res)
+}
+
+func (wx *Weixin) post(name string, url string, body interface{}, res interface{}) error {
+	accessToken, err := wx.GetAccessToken()
+	if err != nil {
+		return err
+	}
+
+	req := resty.New().R().
+		SetQueryParams(map[string]string{
+			"access_token": accessToken.AccessToken,
+		})
+	if body != nil {
+		req.SetBody(body)
+	}
+	resp, err := req.Post(url)
+	if err != nil {
+		return err
+	}
+
+	wx.Logger.Debug(name, wx.Logger.Field("response", resp.Body()), wx.Logger.Field("appId", wx.AppId))
+	return decodeRes(name, resp.Body(), res)
+}
+
+func (wx *Weixin) get(name string, url string, params map[string]string, res interface{}) error {
+	accessToken, err := wx.GetAccessToken()
+	if err != nil {
+		return err
+	}
+
+	resp, err := resty.New().R().
+		SetQueryParams(params).
+		SetQueryParam("access_token", accessToken.AccessToken).
+		Get(url)
+	if err != nil {
+		return err
+	}
+
+	wx.Logger.Debug(name, wx.Logger.Field("response", resp.Body()), wx.Logger.Field("appId", wx.AppId))
+	return decodeRes(name, resp.Body(), res)
+}
@@ -0,0 +1,141 @@
+package weixin
+
+type SecScene int
+
+const (
+	SecSceneProfile SecScene = 1
+	SecSceneComment SecScene = 2
+	SecSceneForum   SecScene = 3
+	SecSceneSocial  SecScene = 4
+)
+
+type SecSuggest string
+
+const (
+	SecSuggestPass   SecSuggest = "pass"
+	SecSuggestReview SecSuggest = "review"
+	SecSuggestRisky  SecSuggest = "risky"
+)
+
+type SecLabel int
+
+const (
+	SecLabelNormal    SecLabel = 100
+	SecLabelAd        SecLabel = 10001
+	SecLabelPolitics  SecLabel = 20001
+	SecLabelPorn      SecLabel = 20002
+	SecLabelAbuse     SecLabel = 20003
+	SecLabelIllegal   SecLabel = 20006
+	SecLabelFraud     SecLabel = 20008
+	SecLabelVulgar    SecLabel = 20012
+	SecLabelCopyright SecLabel = 20013
+	SecLabelOther     SecLabel = 21000
+)
+
+type SecCheckResult struct {
+	Suggest SecSuggest `xml:"suggest" json:"suggest"`
+	Label   SecLabel   `xml:"label" json:"label"`
+}
+
+func (r *SecCheckResult) Pass() bool {
+	return r.Suggest == SecSuggestPass
+}
+
+func (r *SecCheckResult) Risky() bool {
+	return r.Suggest == SecSuggestRisky
+}
+
+type SecCheckDetail struct {
+	Strategy string     `xml:"strategy" json:"strategy"`
+	ErrCode  int        `xml:"errcode" json:"errcode"`
+	Suggest  SecSuggest `xml:"suggest" json:"suggest"`
+	Label    SecLabel   `xml:"label" json:"label"`
+	Keyword  string     `xml:"keyword" json:"keyword"`
+	Prob     int        `xml:"prob" json:"prob"`
+}
+
+type MsgSecCheckReq struct {
+	Content   string   `json:"content"`
+	Scene     SecScene `json:"scene"`
+	OpenId    string   `json:"openid"`
+	Title     string   `json:"title,omitempty"`
+	Nickname  string   `json:"nickname,omitempty"`
+	Signature string   `json:"signature,omitempty"`
+}
+
+type MsgSecCheckRes struct {
+	ErrCode int               `json:"errcode"`
+	ErrMsg  string            `json:"errmsg"`
+	TraceId string            `json:"trace_id"`
+	Result  SecCheckResult    `json:"result"`
+	Detail  []*SecCheckDetail `json:"detail"`
+}
+
+func (wx *Weixin) MsgSecCheck(params *MsgSecCheckReq) (*MsgSecCheckRes, error) {
+	body := &struct {
+		*MsgSecCheckReq
+		Version int `json:"version"`
+	}{
+		MsgSecCheckReq: params,
+		Version:        2,
+	}
+	var res = &MsgSecCheckRes{}
+	if err := wx.post("MsgSecCheck", "https://api.weixin.qq.com/wxa/msg_sec_check", body, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type MediaType int
+
+const (
+	MediaTypeAudio MediaType = 1
+	MediaTypeImage MediaType = 2
+)
+
+type MediaCheckAsyncReq struct {
+	MediaUrl  string    `json:"media_url"`
+	MediaType MediaType `json:"media_type"`
+	Scene     SecScene  `json:"scene"`
+	OpenId    string    `json:"openid"`
+}
+
+type MediaCheckAsyncRes struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	TraceId string `json:"trace_id"`
+}
+
+func (wx *Weixin) MediaCheckAsync(params *MediaCheckAsyncReq) (string, error) {
+	body := &struct {
+		*MediaCheckAsyncReq
+		Version int `json:"version"`
+	}{
+		MediaCheckAsyncReq: params,
+		Version:            2,
+	}
+	var res = &MediaCheckAsyncRes{}
+	if err := wx.post("MediaCheckAsync", "https://api.weixin.qq.com/wxa/media_check_async", body, res); err != nil {
+		return "", err
+	}
+	return res.TraceId, nil
+}
+
+type MediaCheckResult struct {
+	AppId   string            `json:"appid"`
+	TraceId string            `json:"trace_id"`
+	Result  SecCheckResult    `json:"result"`
+	Detail  []*SecCheckDetail `json:"detail"`
+}
+
+func (m *Message) MediaCheckResult() *MediaCheckResult {
+	if m.Event != EventWxaMediaCheck || m.SecResult == nil {
+		return nil
+	}
+	return &MediaCheckResult{
+		AppId:   m.AppId,
+		TraceId: m.TraceId,
+		Result:  *m.SecResult,
+		Detail:  m.SecDetail,
+	}
+}
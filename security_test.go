@@ -0,0 +1,38 @@
+package weixin
+
+import (
+	"testing"
+)
+
+func TestMsgSecCheck(t *testing.T) {
+	result, err := maccount.MsgSecCheck(&MsgSecCheckReq{
+		Content: "content",
+		Scene:   SecSceneComment,
+		OpenId:  "oasi95rPit953LHRYfaifGnTuqgs",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", result.Result)
+}
+
+func TestMediaCheckResult(t *testing.T) {
+	msg, err := ParseMessage([]byte(`{"ToUserName":"gh_38cc49f9733b","FromUserName":"oH1fu0FdHqpToe2T6gBj0WyB8iS1","CreateTime":1626959646,"MsgType":"event","Event":"wxa_media_check","appid":"wx8f16a5e5f9b1f8aa","trace_id":"60f96f1d-3845297a-1976a3ae","version":2,"detail":[{"strategy":"content_model","errcode":0,"suggest":"risky","label":20002,"prob":90}],"errcode":0,"errmsg":"ok","result":{"suggest":"risky","label":20002}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := msg.MediaCheckResult()
+	if result == nil || !result.Result.Risky() || result.Result.Label != SecLabelPorn {
+		t.Fatal("result", result)
+	}
+
+	msg, err = ParseMessage([]byte(`<xml><ToUserName><![CDATA[gh_38cc49f9733b]]></ToUserName><FromUserName><![CDATA[oH1fu0FdHqpToe2T6gBj0WyB8iS1]]></FromUserName><CreateTime>1626959646</CreateTime><MsgType><![CDATA[event]]></MsgType><Event><![CDATA[wxa_media_check]]></Event><appid><![CDATA[wx8f16a5e5f9b1f8aa]]></appid><trace_id><![CDATA[60f96f1d-3845297a-1976a3ae]]></trace_id><version>2</version><detail><strategy><![CDATA[content_model]]></strategy><errcode>0</errcode><suggest><![CDATA[pass]]></suggest><label>100</label><prob>90</prob></detail><errcode>0</errcode><errmsg><![CDATA[ok]]></errmsg><result><suggest><![CDATA[pass]]></suggest><label>100</label></result></xml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = msg.MediaCheckResult()
+	if result == nil || !result.Result.Pass() || len(result.Detail) != 1 {
+		t.Fatal("result", result)
+	}
+	t.Log("result", result.TraceId)
+}
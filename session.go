@@ -0,0 +1,137 @@
+package weixin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"github.com/go-tron/redis"
+	"time"
+)
+
+const (
+	SessionKeyPrefix = "wx-session-key:"
+	SessionKeyExpire = time.Hour * 24 * 30
+)
+
+type SessionKeyStore interface {
+	SaveSessionKey(openId string, sessionKey string) error
+	GetSessionKey(openId string) (string, error)
+}
+
+func NewRedisSessionKeyStore(redis *redis.Redis, appId string) SessionKeyStore {
+	return &RedisSessionKeyStore{
+		Redis: redis,
+		AppId: appId,
+	}
+}
+
+type RedisSessionKeyStore struct {
+	Redis *redis.Redis
+	AppId string
+}
+
+func (s *RedisSessionKeyStore) SaveSessionKey(openId string, sessionKey string) error {
+	return s.Redis.Set(context.Background(), SessionKeyPrefix+s.AppId+":"+openId, sessionKey, SessionKeyExpire).Err()
+}
+
+func (s *RedisSessionKeyStore) GetSessionKey(openId string) (string, error) {
+	sessionKey, err := s.Redis.Get(context.Background(), SessionKeyPrefix+s.AppId+":"+openId).Result()
+	if err == redis.Nil {
+		return "", errors.New("session key not found")
+	}
+	return sessionKey, err
+}
+
+func (wx *Weixin) sessionKeyStore() SessionKeyStore {
+	if wx.SessionKeyStore != nil {
+		return wx.SessionKeyStore
+	}
+	return NewRedisSessionKeyStore(wx.Redis, wx.AppId)
+}
+
+type Session struct {
+	ErrCode    int    `json:"errcode"`
+	ErrMsg     string `json:"errmsg"`
+	OpenId     string `json:"openid"`
+	UnionId    string `json:"unionid"`
+	SessionKey string `json:"session_key"`
+}
+
+func (wx *Weixin) Code2Session(code string) (*Session, error) {
+	url := "https://api.weixin.qq.com/sns/jscode2session"
+	if _, ok := wx.componentTokenSource(); ok {
+		url = "https://api.weixin.qq.com/sns/component/jscode2session"
+	}
+	params, err := wx.snsParams(map[string]string{
+		"js_code":    code,
+		"grant_type": "authorization_code",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := resty.New().R().
+		SetQueryParams(params).
+		Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	var res = &Session{}
+	if err := decodeRes("Code2Session", resp.Body(), res); err != nil {
+		return nil, err
+	}
+	if res.OpenId == "" || res.SessionKey == "" {
+		return nil, errors.New("Code2Session")
+	}
+
+	if err := wx.sessionKeyStore().SaveSessionKey(res.OpenId, res.SessionKey); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (wx *Weixin) GetSessionKey(openId string) (string, error) {
+	return wx.sessionKeyStore().GetSessionKey(openId)
+}
+
+func sessionSignature(sessionKey string) string {
+	mac := hmac.New(sha256.New, []byte(sessionKey))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (wx *Weixin) CheckSession(openId string) error {
+	sessionKey, err := wx.GetSessionKey(openId)
+	if err != nil {
+		return err
+	}
+	return wx.get("CheckSession", "https://api.weixin.qq.com/wxa/checksession", map[string]string{
+		"openid":     openId,
+		"signature":  sessionSignature(sessionKey),
+		"sig_method": "hmac_sha256",
+	}, nil)
+}
+
+func (wx *Weixin) ResetUserSessionKey(openId string) (*Session, error) {
+	sessionKey, err := wx.GetSessionKey(openId)
+	if err != nil {
+		return nil, err
+	}
+
+	var res = &Session{}
+	if err := wx.get("ResetUserSessionKey", "https://api.weixin.qq.com/wxa/resetusersessionkey", map[string]string{
+		"openid":     openId,
+		"signature":  sessionSignature(sessionKey),
+		"sig_method": "hmac_sha256",
+	}, res); err != nil {
+		return nil, err
+	}
+
+	if err := wx.sessionKeyStore().SaveSessionKey(res.OpenId, res.SessionKey); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,236 @@
+package weixin
+
+import (
+	localTime "github.com/go-tron/local-time"
+	"time"
+)
+
+type OrderNumberType int
+
+const (
+	OrderNumberTypeOutTradeNo    OrderNumberType = 1
+	OrderNumberTypeTransactionId OrderNumberType = 2
+)
+
+type LogisticsType int
+
+const (
+	LogisticsTypeExpress  LogisticsType = 1
+	LogisticsTypeSameCity LogisticsType = 2
+	LogisticsTypeVirtual  LogisticsType = 3
+	LogisticsTypeSelfPick LogisticsType = 4
+)
+
+type DeliveryMode int
+
+const (
+	DeliveryModeUnified DeliveryMode = 1
+	DeliveryModeSplit   DeliveryMode = 2
+)
+
+type ShippingOrderState int
+
+const (
+	ShippingOrderStateUnshipped ShippingOrderState = 1
+	ShippingOrderStateShipped   ShippingOrderState = 2
+	ShippingOrderStateConfirmed ShippingOrderState = 3
+	ShippingOrderStateCompleted ShippingOrderState = 4
+	ShippingOrderStateRefunded  ShippingOrderState = 5
+)
+
+type ShippingOrderKey struct {
+	OrderNumberType OrderNumberType `json:"order_number_type"`
+	TransactionId   string          `json:"transaction_id,omitempty"`
+	MchId           string          `json:"mchid,omitempty"`
+	OutTradeNo      string          `json:"out_trade_no,omitempty"`
+}
+
+type ShippingContact struct {
+	ConsignorContact string `json:"consignor_contact,omitempty"`
+	ReceiverContact  string `json:"receiver_contact,omitempty"`
+}
+
+type Shipping struct {
+	TrackingNo     string           `json:"tracking_no,omitempty"`
+	ExpressCompany string           `json:"express_company,omitempty"`
+	ItemDesc       string           `json:"item_desc"`
+	Contact        *ShippingContact `json:"contact,omitempty"`
+	UploadTime     string           `json:"upload_time,omitempty"`
+}
+
+type ShippingPayer struct {
+	OpenId string `json:"openid"`
+}
+
+type UploadShippingInfoReq struct {
+	OrderKey       *ShippingOrderKey `json:"order_key"`
+	LogisticsType  LogisticsType     `json:"logistics_type"`
+	DeliveryMode   DeliveryMode      `json:"delivery_mode"`
+	IsAllDelivered bool              `json:"is_all_delivered"`
+	ShippingList   []*Shipping       `json:"shipping_list"`
+	UploadTime     string            `json:"upload_time"`
+	Payer          *ShippingPayer    `json:"payer"`
+}
+
+func shippingUploadTime() string {
+	return localTime.Now().Format(time.RFC3339)
+}
+
+func (wx *Weixin) UploadShippingInfo(params *UploadShippingInfoReq) error {
+	if params.UploadTime == "" {
+		params.UploadTime = shippingUploadTime()
+	}
+	return wx.post("UploadShippingInfo", "https://api.weixin.qq.com/wxa/sec/order/upload_shipping_info", params, nil)
+}
+
+type ShippingSubOrder struct {
+	OrderKey       *ShippingOrderKey `json:"order_key"`
+	LogisticsType  LogisticsType     `json:"logistics_type"`
+	DeliveryMode   DeliveryMode      `json:"delivery_mode"`
+	IsAllDelivered bool              `json:"is_all_delivered"`
+	ShippingList   []*Shipping       `json:"shipping_list"`
+}
+
+type UploadCombinedShippingInfoReq struct {
+	OrderKey   *ShippingOrderKey   `json:"order_key"`
+	SubOrders  []*ShippingSubOrder `json:"sub_orders"`
+	UploadTime string              `json:"upload_time"`
+	Payer      *ShippingPayer      `json:"payer"`
+}
+
+func (wx *Weixin) UploadCombinedShippingInfo(params *UploadCombinedShippingInfoReq) error {
+	if params.UploadTime == "" {
+		params.UploadTime = shippingUploadTime()
+	}
+	return wx.post("UploadCombinedShippingInfo", "https://api.weixin.qq.com/wxa/sec/order/upload_combined_shipping_info", params, nil)
+}
+
+type ShippingDetail struct {
+	DeliveryMode        DeliveryMode  `json:"delivery_mode"`
+	LogisticsType       LogisticsType `json:"logistics_type"`
+	FinishShipping      bool          `json:"finish_shipping"`
+	GoodsDesc           string        `json:"goods_desc"`
+	FinishShippingCount int           `json:"finish_shipping_count"`
+	ShippingList        []*Shipping   `json:"shipping_list"`
+}
+
+type ShippingOrder struct {
+	TransactionId   string             `json:"transaction_id"`
+	MerchantId      string             `json:"merchant_id"`
+	SubMerchantId   string             `json:"sub_merchant_id"`
+	MerchantTradeNo string             `json:"merchant_trade_no"`
+	Description     string             `json:"description"`
+	PaidAmount      int64              `json:"paid_amount"`
+	OpenId          string             `json:"openid"`
+	TradeCreateTime int64              `json:"trade_create_time"`
+	PayTime         int64              `json:"pay_time"`
+	InComplaint     bool               `json:"in_complaint"`
+	OrderState      ShippingOrderState `json:"order_state"`
+	Shipping        ShippingDetail     `json:"shipping"`
+}
+
+type GetShippingOrderReq struct {
+	TransactionId   string `json:"transaction_id,omitempty"`
+	MerchantId      string `json:"merchant_id,omitempty"`
+	SubMerchantId   string `json:"sub_merchant_id,omitempty"`
+	MerchantTradeNo string `json:"merchant_trade_no,omitempty"`
+}
+
+type GetShippingOrderRes struct {
+	ErrCode int            `json:"errcode"`
+	ErrMsg  string         `json:"errmsg"`
+	Order   *ShippingOrder `json:"order"`
+}
+
+func (wx *Weixin) GetShippingOrder(params *GetShippingOrderReq) (*ShippingOrder, error) {
+	var res = &GetShippingOrderRes{}
+	if err := wx.post("GetShippingOrder", "https://api.weixin.qq.com/wxa/sec/order/get_order", params, res); err != nil {
+		return nil, err
+	}
+	return res.Order, nil
+}
+
+type PayTimeRange struct {
+	BeginTime int64 `json:"begin_time,omitempty"`
+	EndTime   int64 `json:"end_time,omitempty"`
+}
+
+type GetShippingOrderListReq struct {
+	PayTimeRange *PayTimeRange      `json:"pay_time_range,omitempty"`
+	OrderState   ShippingOrderState `json:"order_state,omitempty"`
+	OpenId       string             `json:"openid,omitempty"`
+	LastIndex    string             `json:"last_index,omitempty"`
+	PageSize     int                `json:"page_size,omitempty"`
+}
+
+type GetShippingOrderListRes struct {
+	ErrCode   int              `json:"errcode"`
+	ErrMsg    string           `json:"errmsg"`
+	LastIndex string           `json:"last_index"`
+	HasMore   bool             `json:"has_more"`
+	OrderList []*ShippingOrder `json:"order_list"`
+}
+
+func (wx *Weixin) GetShippingOrderList(params *GetShippingOrderListReq) (*GetShippingOrderListRes, error) {
+	var res = &GetShippingOrderListRes{}
+	if err := wx.post("GetShippingOrderList", "https://api.weixin.qq.com/wxa/sec/order/get_order_list", params, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type NotifyConfirmReceiveReq struct {
+	TransactionId   string `json:"transaction_id,omitempty"`
+	MerchantId      string `json:"merchant_id,omitempty"`
+	SubMerchantId   string `json:"sub_merchant_id,omitempty"`
+	MerchantTradeNo string `json:"merchant_trade_no,omitempty"`
+	ReceivedTime    int64  `json:"received_time"`
+}
+
+func (wx *Weixin) NotifyConfirmReceive(params *NotifyConfirmReceiveReq) error {
+	return wx.post("NotifyConfirmReceive", "https://api.weixin.qq.com/wxa/sec/order/notify_confirm_receive", params, nil)
+}
+
+func (wx *Weixin) SetMsgJumpPath(path string) error {
+	return wx.post("SetMsgJumpPath", "https://api.weixin.qq.com/wxa/sec/order/set_msg_jump_path", map[string]string{
+		"path": path,
+	}, nil)
+}
+
+type ConfirmReceiveMethod int
+
+const (
+	ConfirmReceiveMethodUser ConfirmReceiveMethod = 1
+	ConfirmReceiveMethodAuto ConfirmReceiveMethod = 2
+)
+
+type OrderSettlement struct {
+	TransactionId           string               `json:"transaction_id"`
+	MerchantId              string               `json:"merchant_id"`
+	SubMerchantId           string               `json:"sub_merchant_id"`
+	MerchantTradeNo         string               `json:"merchant_trade_no"`
+	PayTime                 int64                `json:"pay_time"`
+	ShippedTime             int64                `json:"shipped_time"`
+	EstimatedSettlementTime int64                `json:"estimated_settlement_time"`
+	ConfirmReceiveMethod    ConfirmReceiveMethod `json:"confirm_receive_method"`
+	ConfirmReceiveTime      int64                `json:"confirm_receive_time"`
+	SettlementTime          int64                `json:"settlement_time"`
+}
+
+func (m *Message) OrderSettlement() *OrderSettlement {
+	if m.Event != EventTradeManageOrderSettlement {
+		return nil
+	}
+	return &OrderSettlement{
+		TransactionId:           m.TransactionId,
+		MerchantId:              m.MerchantId,
+		SubMerchantId:           m.SubMerchantId,
+		MerchantTradeNo:         m.MerchantTradeNo,
+		PayTime:                 m.PayTime,
+		ShippedTime:             m.ShippedTime,
+		EstimatedSettlementTime: m.EstimatedSettlementTime,
+		ConfirmReceiveMethod:    m.ConfirmReceiveMethod,
+		ConfirmReceiveTime:      m.ConfirmReceiveTime,
+		SettlementTime:          m.SettlementTime,
+	}
+}
@@ -0,0 +1,57 @@
+package weixin
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type SubscribeStatus string
+
+const (
+	SubscribeStatusAccept SubscribeStatus = "accept"
+	SubscribeStatusReject SubscribeStatus = "reject"
+)
+
+type SubscribeMsgEventItem struct {
+	TemplateId            string          `xml:"TemplateId" json:"TemplateId"`
+	SubscribeStatusString SubscribeStatus `xml:"SubscribeStatusString" json:"SubscribeStatusString"`
+	PopupScene            string          `xml:"PopupScene" json:"PopupScene"`
+	MsgId                 string          `xml:"MsgID" json:"MsgID"`
+	ErrorCode             string          `xml:"ErrorCode" json:"ErrorCode"`
+	ErrorStatus           string          `xml:"ErrorStatus" json:"ErrorStatus"`
+}
+
+type SubscribeMsgEventList []*SubscribeMsgEventItem
+
+func (l *SubscribeMsgEventList) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var item = &SubscribeMsgEventItem{}
+		if err := json.Unmarshal(data, item); err != nil {
+			return err
+		}
+		*l = SubscribeMsgEventList{item}
+		return nil
+	}
+	var items []*SubscribeMsgEventItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	*l = items
+	return nil
+}
+
+type SubscribeMsgEvent struct {
+	List SubscribeMsgEventList `xml:"List"`
+}
+
+func (m *Message) SubscribeMsgEvents() SubscribeMsgEventList {
+	if len(m.List) > 0 {
+		return m.List
+	}
+	for _, event := range []*SubscribeMsgEvent{m.SubscribeMsgPopupEvent, m.SubscribeMsgChangeEvent, m.SubscribeMsgSentEvent} {
+		if event != nil {
+			return event.List
+		}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package weixin
+
+import (
+	"errors"
+	"github.com/google/go-querystring/query"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type SubscribeValue struct {
+	Value string `json:"value"`
+}
+
+type SubscribeData map[string]*SubscribeValue
+
+func NewSubscribeData() SubscribeData {
+	return SubscribeData{}
+}
+
+func (d SubscribeData) Set(key string, value string) SubscribeData {
+	d[key] = &SubscribeValue{
+		Value: value,
+	}
+	return d
+}
+
+type BizSendReq struct {
+	OpenId      string               `json:"openId"`
+	TemplateId  string               `json:"templateId"`
+	Page        string               `json:"page"`
+	MiniProgram *TemplateMiniProgram `json:"miniProgram"`
+	Data        SubscribeData        `json:"data"`
+}
+
+func (wx *Weixin) BizSend(params *BizSendReq) error {
+	body := &struct {
+		ToUser      string               `json:"touser"`
+		TemplateId  string               `json:"template_id"`
+		Page        string               `json:"page,omitempty"`
+		MiniProgram *TemplateMiniProgram `json:"miniprogram,omitempty"`
+		Data        SubscribeData        `json:"data"`
+	}{
+		ToUser:      params.OpenId,
+		TemplateId:  params.TemplateId,
+		Page:        params.Page,
+		MiniProgram: params.MiniProgram,
+		Data:        params.Data,
+	}
+	return wx.post("BizSend", "https://api.weixin.qq.com/cgi-bin/message/subscribe/bizsend", body, nil)
+}
+
+type SubscribeCategory struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type GetSubscribeCategoryRes struct {
+	ErrCode int                  `json:"errcode"`
+	ErrMsg  string               `json:"errmsg"`
+	Data    []*SubscribeCategory `json:"data"`
+}
+
+func (wx *Weixin) GetSubscribeCategory() ([]*SubscribeCategory, error) {
+	var res = &GetSubscribeCategoryRes{}
+	if err := wx.get("GetSubscribeCategory", "https://api.weixin.qq.com/wxaapi/newtmpl/getcategory", nil, res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+type GetPubTemplateTitlesReq struct {
+	Ids   []int `json:"ids"`
+	Start int   `json:"start"`
+	Limit int   `json:"limit"`
+}
+
+type PubTemplateTitle struct {
+	Tid        int    `json:"tid"`
+	Title      string `json:"title"`
+	Type       int    `json:"type"`
+	CategoryId string `json:"categoryId"`
+}
+
+type GetPubTemplateTitlesRes struct {
+	ErrCode int                 `json:"errcode"`
+	ErrMsg  string              `json:"errmsg"`
+	Count   int                 `json:"count"`
+	Data    []*PubTemplateTitle `json:"data"`
+}
+
+func (wx *Weixin) GetPubTemplateTitles(params *GetPubTemplateTitlesReq) (*GetPubTemplateTitlesRes, error) {
+	var ids []string
+	for _, id := range params.Ids {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 30
+	}
+
+	var res = &GetPubTemplateTitlesRes{}
+	if err := wx.get("GetPubTemplateTitles", "https://api.weixin.qq.com/wxaapi/newtmpl/getpubtemplatetitles", map[string]string{
+		"ids":   strings.Join(ids, ","),
+		"start": strconv.Itoa(params.Start),
+		"limit": strconv.Itoa(limit),
+	}, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type PubTemplateKeyword struct {
+	Kid     int    `json:"kid"`
+	Name    string `json:"name"`
+	Example string `json:"example"`
+	Rule    string `json:"rule"`
+}
+
+type GetPubTemplateKeywordsRes struct {
+	ErrCode int                   `json:"errcode"`
+	ErrMsg  string                `json:"errmsg"`
+	Count   int                   `json:"count"`
+	Data    []*PubTemplateKeyword `json:"data"`
+}
+
+func (wx *Weixin) GetPubTemplateKeywords(tid string) ([]*PubTemplateKeyword, error) {
+	var res = &GetPubTemplateKeywordsRes{}
+	if err := wx.get("GetPubTemplateKeywords", "https://api.weixin.qq.com/wxaapi/newtmpl/getpubtemplatekeywords", map[string]string{
+		"tid": tid,
+	}, res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+type AddSubscribeTemplateReq struct {
+	Tid       string `json:"tid"`
+	KidList   []int  `json:"kidList"`
+	SceneDesc string `json:"sceneDesc"`
+}
+
+type AddSubscribeTemplateRes struct {
+	ErrCode   int    `json:"errcode"`
+	ErrMsg    string `json:"errmsg"`
+	PriTmplId string `json:"priTmplId"`
+}
+
+func (wx *Weixin) AddSubscribeTemplate(params *AddSubscribeTemplateReq) (string, error) {
+	var res = &AddSubscribeTemplateRes{}
+	if err := wx.post("AddSubscribeTemplate", "https://api.weixin.qq.com/wxaapi/newtmpl/addtemplate", params, res); err != nil {
+		return "", err
+	}
+	return res.PriTmplId, nil
+}
+
+func (wx *Weixin) DelSubscribeTemplate(priTmplId string) error {
+	return wx.post("DelSubscribeTemplate", "https://api.weixin.qq.com/wxaapi/newtmpl/deltemplate", map[string]string{
+		"priTmplId": priTmplId,
+	}, nil)
+}
+
+type SubscribeTemplate struct {
+	PriTmplId string `json:"priTmplId"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	Example   string `json:"example"`
+	Type      int    `json:"type"`
+}
+
+func (t *SubscribeTemplate) Keys() []string {
+	return ParseTemplateKeys(t.Content)
+}
+
+type GetSubscribeTemplateRes struct {
+	ErrCode int                  `json:"errcode"`
+	ErrMsg  string               `json:"errmsg"`
+	Data    []*SubscribeTemplate `json:"data"`
+}
+
+func (wx *Weixin) GetSubscribeTemplate() ([]*SubscribeTemplate, error) {
+	var res = &GetSubscribeTemplateRes{}
+	if err := wx.get("GetSubscribeTemplate", "https://api.weixin.qq.com/wxaapi/newtmpl/gettemplate", nil, res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+type SubscribeMsgUrlReq struct {
+	Scene       int    `json:"scene"`
+	TemplateId  string `json:"templateId"`
+	RedirectUrl string `json:"redirectUrl"`
+	Reserved    string `json:"reserved"`
+}
+
+type SubscribeMsgUrlQuery struct {
+	Action      string `url:"action"`
+	AppId       string `url:"appid"`
+	Scene       int    `url:"scene"`
+	TemplateId  string `url:"template_id"`
+	RedirectUrl string `url:"redirect_url"`
+	Reserved    string `url:"reserved"`
+}
+
+func (wx *Weixin) GetSubscribeMsgUrl(params *SubscribeMsgUrlReq) (string, error) {
+	if params.Scene < 0 || params.Scene > 10000 {
+		return "", errors.New("scene 取值范围0-10000")
+	}
+	req := SubscribeMsgUrlQuery{
+		Action:      "get_confirm",
+		AppId:       wx.AppId,
+		Scene:       params.Scene,
+		TemplateId:  params.TemplateId,
+		RedirectUrl: params.RedirectUrl,
+		Reserved:    params.Reserved,
+	}
+	v, err := query.Values(req)
+	if err != nil {
+		return "", err
+	}
+	return "https://mp.weixin.qq.com/mp/subscribemsg?" + v.Encode() + "#wechat_redirect", nil
+}
+
+type SubscribeMsgConfirm struct {
+	OpenId     string `json:"openid"`
+	TemplateId string `json:"template_id"`
+	Action     string `json:"action"`
+	Scene      int    `json:"scene"`
+	Reserved   string `json:"reserved"`
+}
+
+func (c *SubscribeMsgConfirm) Confirmed() bool {
+	return c.Action == "confirm"
+}
+
+func ParseSubscribeMsgConfirm(values url.Values) (*SubscribeMsgConfirm, error) {
+	if values.Get("openid") == "" {
+		return nil, errors.New("openid 不存在")
+	}
+	scene, _ := strconv.Atoi(values.Get("scene"))
+	return &SubscribeMsgConfirm{
+		OpenId:     values.Get("openid"),
+		TemplateId: values.Get("template_id"),
+		Action:     values.Get("action"),
+		Scene:      scene,
+		Reserved:   values.Get("reserved"),
+	}, nil
+}
+
+type SubscribeMsgReq struct {
+	OpenId      string               `json:"openId"`
+	TemplateId  string               `json:"templateId"`
+	Url         string               `json:"url"`
+	MiniProgram *TemplateMiniProgram `json:"miniProgram"`
+	Scene       int                  `json:"scene"`
+	Title       string               `json:"title"`
+	Content     *TemplateKeyword     `json:"content"`
+}
+
+func (wx *Weixin) SendSubscribeMsg(params *SubscribeMsgReq) error {
+	body := &struct {
+		ToUser      string               `json:"touser"`
+		TemplateId  string               `json:"template_id"`
+		Url         string               `json:"url,omitempty"`
+		MiniProgram *TemplateMiniProgram `json:"miniprogram,omitempty"`
+		Scene       string               `json:"scene"`
+		Title       string               `json:"title"`
+		Data        TemplateData         `json:"data"`
+	}{
+		ToUser:      params.OpenId,
+		TemplateId:  params.TemplateId,
+		Url:         params.Url,
+		MiniProgram: params.MiniProgram,
+		Scene:       strconv.Itoa(params.Scene),
+		Title:       params.Title,
+		Data: TemplateData{
+			"content": params.Content,
+		},
+	}
+	return wx.post("SendSubscribeMsg", "https://api.weixin.qq.com/cgi-bin/message/template/subscribe", body, nil)
+}
@@ -0,0 +1,46 @@
+package weixin
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestGetSubscribeMsgUrl(t *testing.T) {
+	result, err := account.GetSubscribeMsgUrl(&SubscribeMsgUrlReq{
+		Scene:       1000,
+		TemplateId:  "ngqIpbwh8bUfcSsECmogfXcV14J0tQlEpBO27izEYtY",
+		RedirectUrl: "https://weixin.eioos.com/subscribe/return",
+		Reserved:    "order",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", result)
+}
+
+func TestParseSubscribeMsgConfirm(t *testing.T) {
+	values, _ := url.ParseQuery("openid=oasi95rPit953LHRYfaifGnTuqgs&template_id=ngqIpbwh8bUfcSsECmogfXcV14J0tQlEpBO27izEYtY&action=confirm&scene=1000&reserved=order")
+	result, err := ParseSubscribeMsgConfirm(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Confirmed() || result.Scene != 1000 {
+		t.Fatal("result", result)
+	}
+	t.Log("result", result)
+}
+
+func TestBizSend(t *testing.T) {
+	err := account.BizSend(&BizSendReq{
+		OpenId:     "oasi95rPit953LHRYfaifGnTuqgs",
+		TemplateId: "ngqIpbwh8bUfcSsECmogfXcV14J0tQlEpBO27izEYtY",
+		Page:       "https://app.eioos.com",
+		Data: NewSubscribeData().
+			Set("thing1", "thing1").
+			Set("time2", "2023-01-01 00:00:00"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", "success")
+}
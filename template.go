@@ -0,0 +1,125 @@
+package weixin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type IndustryReq struct {
+	IndustryId1 string `json:"industry_id1"`
+	IndustryId2 string `json:"industry_id2"`
+}
+
+func (wx *Weixin) SetIndustry(params *IndustryReq) error {
+	return wx.post("SetIndustry", "https://api.weixin.qq.com/cgi-bin/template/api_set_industry", params, nil)
+}
+
+type Industry struct {
+	FirstClass  string `json:"first_class"`
+	SecondClass string `json:"second_class"`
+}
+
+type GetIndustryRes struct {
+	ErrCode           int      `json:"errcode"`
+	ErrMsg            string   `json:"errmsg"`
+	PrimaryIndustry   Industry `json:"primary_industry"`
+	SecondaryIndustry Industry `json:"secondary_industry"`
+}
+
+func (wx *Weixin) GetIndustry() (*GetIndustryRes, error) {
+	var res = &GetIndustryRes{}
+	if err := wx.get("GetIndustry", "https://api.weixin.qq.com/cgi-bin/template/get_industry", nil, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type AddTemplateReq struct {
+	TemplateIdShort string   `json:"template_id_short"`
+	KeywordNameList []string `json:"keyword_name_list,omitempty"`
+}
+
+type AddTemplateRes struct {
+	ErrCode    int    `json:"errcode"`
+	ErrMsg     string `json:"errmsg"`
+	TemplateId string `json:"template_id"`
+}
+
+func (wx *Weixin) AddTemplate(params *AddTemplateReq) (string, error) {
+	var res = &AddTemplateRes{}
+	if err := wx.post("AddTemplate", "https://api.weixin.qq.com/cgi-bin/template/api_add_template", params, res); err != nil {
+		return "", err
+	}
+	return res.TemplateId, nil
+}
+
+type PrivateTemplate struct {
+	TemplateId      string `json:"template_id"`
+	Title           string `json:"title"`
+	PrimaryIndustry string `json:"primary_industry"`
+	DeputyIndustry  string `json:"deputy_industry"`
+	Content         string `json:"content"`
+	Example         string `json:"example"`
+}
+
+var templateKeyRegexp = regexp.MustCompile(`{{\s*(\w+)\.DATA\s*}}`)
+
+func ParseTemplateKeys(content string) []string {
+	var keys []string
+	for _, match := range templateKeyRegexp.FindAllStringSubmatch(content, -1) {
+		keys = append(keys, match[1])
+	}
+	return keys
+}
+
+func (t *PrivateTemplate) Keys() []string {
+	return ParseTemplateKeys(t.Content)
+}
+
+func (t *PrivateTemplate) Validate(data TemplateData) error {
+	var missing []string
+	for _, key := range t.Keys() {
+		if data[key] == nil {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New(fmt.Sprintf("template %s missing keywords: %s", t.TemplateId, strings.Join(missing, ",")))
+	}
+	return nil
+}
+
+type GetAllPrivateTemplateRes struct {
+	ErrCode      int                `json:"errcode"`
+	ErrMsg       string             `json:"errmsg"`
+	TemplateList []*PrivateTemplate `json:"template_list"`
+}
+
+func (wx *Weixin) GetAllPrivateTemplate() ([]*PrivateTemplate, error) {
+	var res = &GetAllPrivateTemplateRes{}
+	if err := wx.get("GetAllPrivateTemplate", "https://api.weixin.qq.com/cgi-bin/template/get_all_private_template", nil, res); err != nil {
+		return nil, err
+	}
+	return res.TemplateList, nil
+}
+
+func (wx *Weixin) DelPrivateTemplate(templateId string) error {
+	return wx.post("DelPrivateTemplate", "https://api.weixin.qq.com/cgi-bin/template/del_private_template", map[string]string{
+		"template_id": templateId,
+	}, nil)
+}
+
+func (wx *Weixin) ValidateTemplate(template *TemplateReq) error {
+	templates, err := wx.GetAllPrivateTemplate()
+	if err != nil {
+		return err
+	}
+	for _, t := range templates {
+		if t.TemplateId == template.TemplateId {
+			return t.Validate(template.Data)
+		}
+	}
+	return errors.New(fmt.Sprintf("template %s not found", template.TemplateId))
+}
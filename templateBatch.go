@@ -0,0 +1,288 @@
+package weixin
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/go-tron/redis"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	TemplateBatchPrefix = "wx-template-batch:"
+	TemplateBatchExpire = time.Hour * 24 * 7
+	TemplateBatchMaxQPS = 1000
+)
+
+var templatePermanentErrCodes = map[int]bool{
+	40003: true, // invalid openid
+	40036: true, // invalid template_id size
+	40037: true, // invalid template_id
+	43004: true, // require subscribe
+	43101: true, // user refuse to accept the msg
+	47001: true, // data format error
+	47003: true, // argument invalid
+}
+
+var templateRetryErrCodes = map[int]bool{
+	-1:    true, // system busy
+	40001: true, // invalid credential
+	42001: true, // access_token expired
+	45011: true, // api minute-quota reach limit
+}
+
+func isTemplatePermanentError(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && templatePermanentErrCodes[e.Code]
+}
+
+// template/send is not idempotent, so a transport error is only retried when
+// the request never reached WeChat
+func isTemplateRetryError(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return templateRetryErrCodes[e.Code]
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+type TemplateBatchCheckpoint interface {
+	IsTemplateBatchDone(batchId string, key string) (bool, error)
+	MarkTemplateBatchDone(batchId string, key string) error
+}
+
+func NewRedisTemplateBatchCheckpoint(redis *redis.Redis, appId string) TemplateBatchCheckpoint {
+	return &RedisTemplateBatchCheckpoint{
+		Redis: redis,
+		AppId: appId,
+	}
+}
+
+type RedisTemplateBatchCheckpoint struct {
+	Redis *redis.Redis
+	AppId string
+}
+
+func (c *RedisTemplateBatchCheckpoint) IsTemplateBatchDone(batchId string, key string) (bool, error) {
+	return c.Redis.SIsMember(context.Background(), TemplateBatchPrefix+c.AppId+":"+batchId, key).Result()
+}
+
+func (c *RedisTemplateBatchCheckpoint) MarkTemplateBatchDone(batchId string, key string) error {
+	k := TemplateBatchPrefix + c.AppId + ":" + batchId
+	pipe := c.Redis.TxPipeline()
+	pipe.SAdd(context.Background(), k, key)
+	pipe.Expire(context.Background(), k, TemplateBatchExpire)
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+type TemplateBatchReq struct {
+	BatchId       string                  `json:"batchId"`
+	QPS           int                     `json:"qps"`
+	Workers       int                     `json:"workers"`
+	MaxRetries    int                     `json:"maxRetries"`
+	RetryInterval time.Duration           `json:"retryInterval"`
+	Templates     []*TemplateReq          `json:"templates"`
+	Checkpoint    TemplateBatchCheckpoint `json:"-"`
+}
+
+type TemplateBatchFailure struct {
+	OpenId    string `json:"openId"`
+	Error     string `json:"error"`
+	Permanent bool   `json:"permanent"`
+}
+
+type TemplateBatchReport struct {
+	BatchId   string                  `json:"batchId"`
+	Total     int                     `json:"total"`
+	Sent      int                     `json:"sent"`
+	Skipped   int                     `json:"skipped"`
+	Rejected  int                     `json:"rejected"`
+	Failed    int                     `json:"failed"`
+	Failures  []*TemplateBatchFailure `json:"failures"`
+	StartedAt time.Time               `json:"startedAt"`
+	Duration  time.Duration           `json:"duration"`
+}
+
+func (r *TemplateBatchReport) Completed() bool {
+	return r.Sent+r.Skipped+r.Rejected == r.Total
+}
+
+func templateBatchKey(index int, template *TemplateReq) string {
+	if template.ClientMsgId != "" {
+		return template.ClientMsgId
+	}
+	data, _ := json.Marshal(template)
+	hash := sha1.Sum(data)
+	return strconv.Itoa(index) + ":" + hex.EncodeToString(hash[:])
+}
+
+// client_msg_id lets WeChat drop a duplicate of a message it already accepted
+func templateBatchClientMsgId(batchId string, key string) string {
+	hash := sha1.Sum([]byte(batchId + ":" + key))
+	return hex.EncodeToString(hash[:])
+}
+
+type templateBatchJob struct {
+	index    int
+	template *TemplateReq
+}
+
+func (wx *Weixin) sendTemplateWithRetry(ctx context.Context, params *TemplateBatchReq, limiter <-chan time.Time, template *TemplateReq) error {
+	var err error
+	for attempt := 0; attempt <= params.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(params.RetryInterval * time.Duration(attempt)):
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-limiter:
+		}
+
+		_, err = wx.SendTemplate(template)
+		if err == nil || !isTemplateRetryError(err) {
+			return err
+		}
+		var e *Error
+		if errors.As(err, &e) && (e.Code == 40001 || e.Code == 42001) {
+			if err := wx.ResetAccessToken(); err != nil {
+				wx.Logger.Error("SendTemplateBatch reset access token", wx.Logger.Field("error", err), wx.Logger.Field("appId", wx.AppId))
+			}
+		}
+		wx.Logger.Warn("SendTemplateBatch retry", wx.Logger.Field("error", err), wx.Logger.Field("openId", template.OpenId), wx.Logger.Field("attempt", attempt), wx.Logger.Field("appId", wx.AppId))
+	}
+	return err
+}
+
+func (wx *Weixin) SendTemplateBatch(ctx context.Context, req *TemplateBatchReq) (*TemplateBatchReport, error) {
+	if req.BatchId == "" {
+		return nil, errors.New("batchId 必须设置")
+	}
+	p := *req
+	params := &p
+	if params.QPS <= 0 {
+		params.QPS = 20
+	} else if params.QPS > TemplateBatchMaxQPS {
+		params.QPS = TemplateBatchMaxQPS
+	}
+	if params.Workers <= 0 {
+		params.Workers = 4
+	}
+	if params.MaxRetries == 0 {
+		params.MaxRetries = 3
+	} else if params.MaxRetries < 0 {
+		params.MaxRetries = 0
+	}
+	if params.RetryInterval <= 0 {
+		params.RetryInterval = time.Second
+	}
+	checkpoint := params.Checkpoint
+	if checkpoint == nil {
+		checkpoint = NewRedisTemplateBatchCheckpoint(wx.Redis, wx.AppId)
+	}
+
+	report := &TemplateBatchReport{
+		BatchId:   params.BatchId,
+		Total:     len(params.Templates),
+		StartedAt: time.Now(),
+	}
+	var lock sync.Mutex
+	fail := func(template *TemplateReq, err error, permanent bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		if permanent {
+			report.Rejected++
+		} else {
+			report.Failed++
+		}
+		report.Failures = append(report.Failures, &TemplateBatchFailure{
+			OpenId:    template.OpenId,
+			Error:     err.Error(),
+			Permanent: permanent,
+		})
+	}
+
+	limiter := time.NewTicker(time.Second / time.Duration(params.QPS))
+	defer limiter.Stop()
+
+	jobs := make(chan *templateBatchJob)
+	var wg sync.WaitGroup
+	for i := 0; i < params.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				template := job.template
+				key := templateBatchKey(job.index, template)
+				done, err := checkpoint.IsTemplateBatchDone(params.BatchId, key)
+				if err != nil {
+					fail(template, err, false)
+					continue
+				}
+				if done {
+					lock.Lock()
+					report.Skipped++
+					lock.Unlock()
+					continue
+				}
+
+				if template.ClientMsgId == "" {
+					t := *template
+					t.ClientMsgId = templateBatchClientMsgId(params.BatchId, key)
+					template = &t
+				}
+				err = wx.sendTemplateWithRetry(ctx, params, limiter.C, template)
+				if err != nil && ctx.Err() != nil {
+					continue
+				}
+				if err != nil && !isTemplatePermanentError(err) {
+					fail(template, err, false)
+					continue
+				}
+				if err != nil {
+					fail(template, err, true)
+				} else {
+					lock.Lock()
+					report.Sent++
+					lock.Unlock()
+				}
+				if err := checkpoint.MarkTemplateBatchDone(params.BatchId, key); err != nil {
+					wx.Logger.Error("SendTemplateBatch checkpoint", wx.Logger.Field("error", err), wx.Logger.Field("batchId", params.BatchId), wx.Logger.Field("appId", wx.AppId))
+				}
+			}
+		}()
+	}
+
+feed:
+	for i, template := range params.Templates {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- &templateBatchJob{index: i, template: template}:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	report.Duration = time.Since(report.StartedAt)
+	wx.Logger.Info("SendTemplateBatch",
+		wx.Logger.Field("batchId", report.BatchId),
+		wx.Logger.Field("total", report.Total),
+		wx.Logger.Field("sent", report.Sent),
+		wx.Logger.Field("skipped", report.Skipped),
+		wx.Logger.Field("rejected", report.Rejected),
+		wx.Logger.Field("failed", report.Failed),
+		wx.Logger.Field("appId", wx.AppId))
+	return report, ctx.Err()
+}
@@ -0,0 +1,124 @@
+package weixin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	localTime "github.com/go-tron/local-time"
+	"github.com/go-tron/redis"
+	"strconv"
+	"time"
+)
+
+const (
+	TemplateSendPrefix = "wx-template-send:"
+	TemplateSendExpire = time.Hour * 24 * 7
+)
+
+type TemplateSendStatus string
+
+const (
+	TemplateSendStatusSending      TemplateSendStatus = "sending"
+	TemplateSendStatusSuccess      TemplateSendStatus = "success"
+	TemplateSendStatusUserBlock    TemplateSendStatus = "failed:user block"
+	TemplateSendStatusSystemFailed TemplateSendStatus = "failed: system failed"
+)
+
+var ErrTemplateSendNotFound = errors.New("template send record not found")
+
+type TemplateSendRecord struct {
+	MsgId       int64              `json:"msgId"`
+	OpenId      string             `json:"openId"`
+	TemplateId  string             `json:"templateId"`
+	ClientMsgId string             `json:"clientMsgId"`
+	Status      TemplateSendStatus `json:"status"`
+	CreatedAt   int64              `json:"createdAt"`
+	FinishedAt  int64              `json:"finishedAt"`
+}
+
+func (r *TemplateSendRecord) Finished() bool {
+	return r.Status != TemplateSendStatusSending
+}
+
+type TemplateSendStore interface {
+	SaveTemplateSend(record *TemplateSendRecord) error
+	GetTemplateSend(msgId int64) (*TemplateSendRecord, error)
+}
+
+func NewRedisTemplateSendStore(redis *redis.Redis, appId string) TemplateSendStore {
+	return &RedisTemplateSendStore{
+		Redis: redis,
+		AppId: appId,
+	}
+}
+
+type RedisTemplateSendStore struct {
+	Redis *redis.Redis
+	AppId string
+}
+
+func (s *RedisTemplateSendStore) SaveTemplateSend(record *TemplateSendRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.Redis.Set(context.Background(), TemplateSendPrefix+s.AppId+":"+strconv.FormatInt(record.MsgId, 10), data, TemplateSendExpire).Err()
+}
+
+func (s *RedisTemplateSendStore) GetTemplateSend(msgId int64) (*TemplateSendRecord, error) {
+	data, err := s.Redis.Get(context.Background(), TemplateSendPrefix+s.AppId+":"+strconv.FormatInt(msgId, 10)).Result()
+	if err == redis.Nil {
+		return nil, ErrTemplateSendNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record = &TemplateSendRecord{}
+	if err := json.Unmarshal([]byte(data), record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (wx *Weixin) templateSendStore() TemplateSendStore {
+	if wx.TemplateSendStore != nil {
+		return wx.TemplateSendStore
+	}
+	return NewRedisTemplateSendStore(wx.Redis, wx.AppId)
+}
+
+func (wx *Weixin) recordTemplateSend(template *TemplateReq, msgId int64) {
+	err := wx.templateSendStore().SaveTemplateSend(&TemplateSendRecord{
+		MsgId:       msgId,
+		OpenId:      template.OpenId,
+		TemplateId:  template.TemplateId,
+		ClientMsgId: template.ClientMsgId,
+		Status:      TemplateSendStatusSending,
+		CreatedAt:   localTime.Now().Unix(),
+	})
+	if err != nil {
+		wx.Logger.Error("recordTemplateSend", wx.Logger.Field("error", err), wx.Logger.Field("msgId", msgId), wx.Logger.Field("appId", wx.AppId))
+	}
+}
+
+func (wx *Weixin) GetTemplateSend(msgId int64) (*TemplateSendRecord, error) {
+	return wx.templateSendStore().GetTemplateSend(msgId)
+}
+
+func (wx *Weixin) FinishTemplateSend(msg *Message) error {
+	store := wx.templateSendStore()
+	record, err := store.GetTemplateSend(msg.JobMsgId)
+	if err != nil && !errors.Is(err, ErrTemplateSendNotFound) {
+		return err
+	}
+	if err != nil {
+		wx.Logger.Debug("FinishTemplateSend", wx.Logger.Field("error", err), wx.Logger.Field("msgId", msg.JobMsgId), wx.Logger.Field("appId", wx.AppId))
+		record = &TemplateSendRecord{
+			MsgId:  msg.JobMsgId,
+			OpenId: msg.FromUserName,
+		}
+	}
+	record.Status = TemplateSendStatus(msg.Status)
+	record.FinishedAt = msg.CreateTime
+	return store.SaveTemplateSend(record)
+}
@@ -0,0 +1,178 @@
+package weixin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestParseTemplateKeys(t *testing.T) {
+	keys := ParseTemplateKeys("{{first.DATA}}\n订单号：{{keyword1.DATA}}\n金额：{{ keyword2.DATA }}\n{{remark.DATA}}")
+	if len(keys) != 4 || keys[0] != "first" || keys[2] != "keyword2" {
+		t.Fatal("keys", keys)
+	}
+
+	template := &PrivateTemplate{
+		TemplateId: "0sWTgTRNs91psQ8PSkREh8-4h1ziHIQsvmdfyqTc6Qk",
+		Content:    "{{first.DATA}}\n订单号：{{keyword1.DATA}}\n{{remark.DATA}}",
+	}
+	if err := template.Validate(NewTemplateData().
+		Set("first", "first").
+		Set("keyword1", "keyword1")); err == nil {
+		t.Fatal("missing remark not detected")
+	}
+	t.Log("result", keys)
+}
+
+func TestGetAllPrivateTemplate(t *testing.T) {
+	result, err := account.GetAllPrivateTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", result)
+}
+
+func TestFinishTemplateSend(t *testing.T) {
+	account.recordTemplateSend(&TemplateReq{
+		OpenId:     "oasi95rPit953LHRYfaifGnTuqgs",
+		TemplateId: "0sWTgTRNs91psQ8PSkREh8-4h1ziHIQsvmdfyqTc6Qk",
+	}, 200163840)
+	msg, err := ParseMessage([]byte(`<xml><ToUserName><![CDATA[gh_7f083739789a]]></ToUserName><FromUserName><![CDATA[oasi95rPit953LHRYfaifGnTuqgs]]></FromUserName><CreateTime>1395658984</CreateTime><MsgType><![CDATA[event]]></MsgType><Event><![CDATA[TEMPLATESENDJOBFINISH]]></Event><MsgID>200163840</MsgID><Status><![CDATA[failed:user block]]></Status></xml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := account.HandleMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	record, err := account.GetTemplateSend(200163840)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != TemplateSendStatusUserBlock || record.TemplateId == "" {
+		t.Fatal("record", record)
+	}
+	t.Log("result", record)
+}
+
+func TestTemplateBatchErrors(t *testing.T) {
+	if !isTemplatePermanentError(&Error{Code: 43004, Msg: "require subscribe"}) {
+		t.Fatal("43004 should be permanent")
+	}
+	if isTemplateRetryError(&Error{Code: 43101, Msg: "user refuse to accept the msg"}) {
+		t.Fatal("43101 should not be retried")
+	}
+	if !isTemplateRetryError(&Error{Code: -1, Msg: "system error"}) {
+		t.Fatal("-1 should be retried")
+	}
+	if !isTemplateRetryError(&url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}) {
+		t.Fatal("dial error should be retried")
+	}
+	if isTemplateRetryError(&url.Error{Op: "Post", Err: &net.OpError{Op: "read", Err: errors.New("i/o timeout")}}) {
+		t.Fatal("read timeout may have been delivered and should not be retried")
+	}
+	if id := templateBatchClientMsgId("order-notify", "0:abc"); len(id) != 40 || id != templateBatchClientMsgId("order-notify", "0:abc") {
+		t.Fatal("clientMsgId", id)
+	}
+}
+
+func TestSendTemplateBatch(t *testing.T) {
+	report, err := account.SendTemplateBatch(context.Background(), &TemplateBatchReq{
+		BatchId: "order-notify",
+		QPS:     10,
+		Templates: []*TemplateReq{
+			{
+				OpenId:     "oasi95rPit953LHRYfaifGnTuqgs",
+				TemplateId: "0sWTgTRNs91psQ8PSkREh8-4h1ziHIQsvmdfyqTc6Qk",
+				Data: NewTemplateData().
+					Set("first", "first").
+					Set("remark", "remark"),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("result", report)
+}
+
+type testTemplateBatchCheckpoint struct {
+	lock sync.Mutex
+	keys map[string]bool
+}
+
+func (c *testTemplateBatchCheckpoint) IsTemplateBatchDone(batchId string, key string) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.keys[key] = true
+	return true, nil
+}
+
+func (c *testTemplateBatchCheckpoint) MarkTemplateBatchDone(batchId string, key string) error {
+	return nil
+}
+
+func TestTemplateBatchSameOpenId(t *testing.T) {
+	checkpoint := &testTemplateBatchCheckpoint{keys: make(map[string]bool)}
+	params := &TemplateBatchReq{
+		BatchId:    "order-notify",
+		Checkpoint: checkpoint,
+		Templates: []*TemplateReq{
+			{
+				OpenId:     "oasi95rPit953LHRYfaifGnTuqgs",
+				TemplateId: "0sWTgTRNs91psQ8PSkREh8-4h1ziHIQsvmdfyqTc6Qk",
+				Data:       NewTemplateData().Set("first", "order 1001"),
+			},
+			{
+				OpenId:     "oasi95rPit953LHRYfaifGnTuqgs",
+				TemplateId: "0sWTgTRNs91psQ8PSkREh8-4h1ziHIQsvmdfyqTc6Qk",
+				Data:       NewTemplateData().Set("first", "order 1002"),
+			},
+		},
+	}
+	report, err := account.SendTemplateBatch(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoint.keys) != 2 || report.Skipped != 2 {
+		t.Fatal("keys", checkpoint.keys, report)
+	}
+	if params.QPS != 0 || params.Workers != 0 || params.MaxRetries != 0 {
+		t.Fatal("params modified", params)
+	}
+}
+
+type testTemplateSendStore struct {
+	err   error
+	saved int
+}
+
+func (s *testTemplateSendStore) SaveTemplateSend(record *TemplateSendRecord) error {
+	s.saved++
+	return nil
+}
+
+func (s *testTemplateSendStore) GetTemplateSend(msgId int64) (*TemplateSendRecord, error) {
+	return nil, s.err
+}
+
+func TestFinishTemplateSendStoreError(t *testing.T) {
+	msg := &Message{JobMsgId: 200163841, Status: string(TemplateSendStatusSuccess)}
+	for _, store := range []*testTemplateSendStore{
+		{err: errors.New("store unavailable")},
+		{err: ErrTemplateSendNotFound},
+	} {
+		config := *account.Config
+		config.TemplateSendStore = store
+		wx := &Weixin{Config: &config}
+		err := wx.FinishTemplateSend(msg)
+		if store.err == ErrTemplateSendNotFound && (err != nil || store.saved != 1) {
+			t.Fatal("missing record", err, store.saved)
+		}
+		if store.err != ErrTemplateSendNotFound && (err == nil || store.saved != 0) {
+			t.Fatal("store error", err, store.saved)
+		}
+	}
+}
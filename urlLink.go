@@ -0,0 +1,131 @@
+package weixin
+
+type ExpireType int
+
+const (
+	ExpireTypeTime     ExpireType = 0
+	ExpireTypeInterval ExpireType = 1
+)
+
+type JumpWxa struct {
+	Path       string     `json:"path"`
+	Query      string     `json:"query"`
+	EnvVersion EnvVersion `json:"env_version,omitempty"`
+}
+
+type GenerateSchemeReq struct {
+	JumpWxa        *JumpWxa   `json:"jump_wxa,omitempty"`
+	IsExpire       bool       `json:"is_expire"`
+	ExpireType     ExpireType `json:"expire_type"`
+	ExpireTime     int64      `json:"expire_time,omitempty"`
+	ExpireInterval int        `json:"expire_interval,omitempty"`
+}
+
+type GenerateSchemeRes struct {
+	ErrCode  int    `json:"errcode"`
+	ErrMsg   string `json:"errmsg"`
+	OpenLink string `json:"openlink"`
+}
+
+func (wx *Weixin) GenerateScheme(params *GenerateSchemeReq) (string, error) {
+	var res = &GenerateSchemeRes{}
+	if err := wx.post("GenerateScheme", "https://api.weixin.qq.com/wxa/generatescheme", params, res); err != nil {
+		return "", err
+	}
+	return res.OpenLink, nil
+}
+
+type GenerateUrlLinkReq struct {
+	Path           string     `json:"path"`
+	Query          string     `json:"query"`
+	EnvVersion     EnvVersion `json:"env_version,omitempty"`
+	IsExpire       bool       `json:"is_expire"`
+	ExpireType     ExpireType `json:"expire_type"`
+	ExpireTime     int64      `json:"expire_time,omitempty"`
+	ExpireInterval int        `json:"expire_interval,omitempty"`
+}
+
+type GenerateUrlLinkRes struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	UrlLink string `json:"url_link"`
+}
+
+func (wx *Weixin) GenerateUrlLink(params *GenerateUrlLinkReq) (string, error) {
+	var res = &GenerateUrlLinkRes{}
+	if err := wx.post("GenerateUrlLink", "https://api.weixin.qq.com/wxa/generate_urllink", params, res); err != nil {
+		return "", err
+	}
+	return res.UrlLink, nil
+}
+
+type GenerateShortLinkReq struct {
+	PageUrl     string `json:"page_url"`
+	PageTitle   string `json:"page_title,omitempty"`
+	IsPermanent bool   `json:"is_permanent"`
+}
+
+type GenerateShortLinkRes struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	Link    string `json:"link"`
+}
+
+func (wx *Weixin) GenerateShortLink(params *GenerateShortLinkReq) (string, error) {
+	var res = &GenerateShortLinkRes{}
+	if err := wx.post("GenerateShortLink", "https://api.weixin.qq.com/wxa/genwxashortlink", params, res); err != nil {
+		return "", err
+	}
+	return res.Link, nil
+}
+
+type LinkInfo struct {
+	AppId      string     `json:"appid"`
+	Path       string     `json:"path"`
+	Query      string     `json:"query"`
+	CreateTime int64      `json:"create_time"`
+	ExpireTime int64      `json:"expire_time"`
+	EnvVersion EnvVersion `json:"env_version"`
+}
+
+type LinkQuotaInfo struct {
+	RemainVisitQuota int64 `json:"remain_visit_quota"`
+}
+
+type QuerySchemeRes struct {
+	ErrCode     int           `json:"errcode"`
+	ErrMsg      string        `json:"errmsg"`
+	SchemeInfo  LinkInfo      `json:"scheme_info"`
+	VisitOpenId string        `json:"visit_openid"`
+	QuotaInfo   LinkQuotaInfo `json:"quota_info"`
+}
+
+func (wx *Weixin) QueryScheme(scheme string) (*QuerySchemeRes, error) {
+	var res = &QuerySchemeRes{}
+	if err := wx.post("QueryScheme", "https://api.weixin.qq.com/wxa/queryscheme", map[string]interface{}{
+		"scheme":     scheme,
+		"query_type": 0,
+	}, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type QueryUrlLinkRes struct {
+	ErrCode     int           `json:"errcode"`
+	ErrMsg      string        `json:"errmsg"`
+	UrlLinkInfo LinkInfo      `json:"url_link_info"`
+	VisitOpenId string        `json:"visit_openid"`
+	QuotaInfo   LinkQuotaInfo `json:"quota_info"`
+}
+
+func (wx *Weixin) QueryUrlLink(urlLink string) (*QueryUrlLinkRes, error) {
+	var res = &QueryUrlLinkRes{}
+	if err := wx.post("QueryUrlLink", "https://api.weixin.qq.com/wxa/query_urllink", map[string]interface{}{
+		"url_link":   urlLink,
+		"query_type": 0,
+	}, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
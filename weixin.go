@@ -31,14 +31,16 @@ func New(c *Config) *Weixin {
 	if c == nil {
 		panic("config 必须设置")
 	}
-	if c.Username == "" {
-		panic("Username 必须设置")
-	}
-	if c.Password == "" {
-		panic("Password 必须设置")
-	}
-	if c.BaseUrl == "" {
-		panic("BaseUrl 必须设置")
+	if c.TokenSource == nil {
+		if c.Username == "" {
+			panic("Username 必须设置")
+		}
+		if c.Password == "" {
+			panic("Password 必须设置")
+		}
+		if c.BaseUrl == "" {
+			panic("BaseUrl 必须设置")
+		}
 	}
 	if c.Name == "" {
 		panic("Name 必须设置")
@@ -46,7 +48,7 @@ func New(c *Config) *Weixin {
 	if c.AppId == "" {
 		panic("AppId 必须设置")
 	}
-	if c.Secret == "" {
+	if c.Secret == "" && c.TokenSource == nil {
 		panic("Secret 必须设置")
 	}
 	if c.Logger == nil {
@@ -80,17 +82,24 @@ type Weixin struct {
 }
 
 type Config struct {
-	Username         string        `json:"username"`
-	Password         string        `json:"password"`
-	BaseUrl          string        `json:"baseUrl"`
-	Name             string        `json:"name"`
-	AppId            string        `json:"appId"`
-	Secret           string        `json:"secret"`
-	Token            string        `json:"token"`
-	SubscribeUrl     string        `json:"subscribeUrl"`
-	OAuthRedirectUri string        `json:"oAuthRedirectUri"`
-	Logger           logger.Logger `json:"logger"`
-	Redis            *redis.Redis  `json:"redis"`
+	Username          string            `json:"username"`
+	Password          string            `json:"password"`
+	BaseUrl           string            `json:"baseUrl"`
+	Name              string            `json:"name"`
+	AppId             string            `json:"appId"`
+	Secret            string            `json:"secret"`
+	Token             string            `json:"token"`
+	SubscribeUrl      string            `json:"subscribeUrl"`
+	OAuthRedirectUri  string            `json:"oAuthRedirectUri"`
+	Logger            logger.Logger     `json:"logger"`
+	Redis             *redis.Redis      `json:"redis"`
+	MassJobStore      MassJobStore      `json:"-"`
+	TemplateSendStore TemplateSendStore `json:"-"`
+	SessionKeyStore   SessionKeyStore   `json:"-"`
+	PhoneBindingStore PhoneBindingStore `json:"-"`
+	WxaCodeCache      BlobStore         `json:"-"`
+	QrCodeSceneStore  QrCodeSceneStore  `json:"-"`
+	TokenSource       base.TokenSource  `json:"-"`
 }
 
 type AccessTokenRes struct {
@@ -110,6 +119,20 @@ func (wx *Weixin) ClearAccessToken() {
 	}
 }
 
+func (wx *Weixin) componentTokenSource() (base.ComponentTokenSource, bool) {
+	source, ok := wx.TokenSource.(base.ComponentTokenSource)
+	return source, ok
+}
+
+func (wx *Weixin) ResetAccessToken() error {
+	wx.ClearAccessToken()
+	if wx.TokenSource != nil {
+		return wx.TokenSource.ResetAccessToken(wx.AppId)
+	}
+	_, err := wx.Redis.Del(context.Background(), base.AccessTokenPrefix+wx.AppId).Result()
+	return err
+}
+
 func (wx *Weixin) SetAccessToken(accessToken string, expiresIn int64) {
 
 	wx.accessToken = &AccessToken{
@@ -180,6 +203,16 @@ func (wx *Weixin) GetAccessToken() (a *AccessToken, err error) {
 		return wx.accessToken, nil
 	}
 
+	if wx.TokenSource != nil {
+		accessToken, expiresIn, err := wx.TokenSource.GetAccessToken(wx.AppId)
+		if err != nil {
+			return nil, err
+		}
+		wx.SetAccessToken(accessToken, expiresIn)
+		wx.Logger.Debug("GetAccessToken from token source", wx.Logger.Field("appId", wx.AppId))
+		return wx.accessToken, nil
+	}
+
 	accessToken, err := wx.Redis.Get(context.Background(), base.AccessTokenPrefix+wx.AppId).Result()
 	ttl, err := wx.Redis.TTL(context.Background(), base.AccessTokenPrefix+wx.AppId).Result()
 	if accessToken != "" && ttl > 0 {
@@ -240,6 +273,19 @@ func (wx *Weixin) GetJsApiTicket() (j *JsApiTicket, err error) {
 		return wx.jsApiTicket, nil
 	}
 
+	if wx.TokenSource != nil {
+		var res = &base.JsApiTicketRes{}
+		if err := wx.get("GetJsApiTicket", "https://api.weixin.qq.com/cgi-bin/ticket/getticket", map[string]string{
+			"type": "jsapi",
+		}, res); err != nil {
+			return nil, err
+		}
+		wx.SetJsApiTicket(res.Ticket, res.ExpiresIn)
+		wx.Redis.Set(context.Background(), base.JsApiTicketPrefix+wx.AppId, res.Ticket, time.Second*time.Duration(res.ExpiresIn)).Result()
+		wx.Logger.Debug("GetJsApiTicket from request", wx.Logger.Field("appId", wx.AppId))
+		return wx.jsApiTicket, nil
+	}
+
 	resp, err := resty.New().R().
 		SetBody(map[string]string{
 			"appId":  wx.AppId,
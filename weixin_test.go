@@ -3,6 +3,7 @@ package weixin
 import (
 	"github.com/go-tron/logger"
 	"github.com/go-tron/redis"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -102,69 +103,41 @@ func TestGetUserInfo(t *testing.T) {
 }
 
 func TestSendStartTemplate(t *testing.T) {
-	err := account.SendTemplate(&TemplateReq{
+	msgId, err := account.SendTemplate(&TemplateReq{
 		OpenId:     "oasi95rPit953LHRYfaifGnTuqgs",
 		TemplateId: "0sWTgTRNs91psQ8PSkREh8-4h1ziHIQsvmdfyqTc6Qk",
 		Url:        "https://app.eioos.com",
-		Data: map[string]interface{}{
-			"first": map[string]string{
-				"value": "first",
-			},
-			"keyword1": map[string]string{
-				"value": "keyword1",
-			},
-			"keyword2": map[string]string{
-				"value": "keyword2",
-			},
-			"keyword3": map[string]string{
-				"value": "keyword3",
-			},
-			"keyword4": map[string]string{
-				"value": "keyword4",
-			},
-			"remark": map[string]string{
-				"value": "remark",
-				"color": "#173177",
-			},
-		},
+		Data: NewTemplateData().
+			Set("first", "first").
+			Set("keyword1", "keyword1").
+			Set("keyword2", "keyword2").
+			Set("keyword3", "keyword3").
+			Set("keyword4", "keyword4").
+			SetWithColor("remark", "remark", "#173177"),
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log("result", "success")
+	t.Log("result", msgId)
 }
 
 func TestSendFinishTemplate(t *testing.T) {
-	err := account.SendTemplate(&TemplateReq{
+	msgId, err := account.SendTemplate(&TemplateReq{
 		OpenId:     "opZow6IEeQkp1y03HWfjZW0njPUE",
 		TemplateId: "je8cWU_cvGgDMlPMAK87DmJ9p4In11GnHRkaIW19IbU",
 		Url:        "https://app.eioos.com",
-		Data: map[string]interface{}{
-			"first": map[string]string{
-				"value": "first",
-			},
-			"keyword1": map[string]string{
-				"value": "keyword1",
-			},
-			"keyword2": map[string]string{
-				"value": "keyword2",
-			},
-			"keyword3": map[string]string{
-				"value": "keyword3",
-			},
-			"keyword4": map[string]string{
-				"value": "keyword4",
-			},
-			"remark": map[string]string{
-				"value": "remark",
-				"color": "#173177",
-			},
-		},
+		Data: NewTemplateData().
+			Set("first", "first").
+			Set("keyword1", "keyword1").
+			Set("keyword2", "keyword2").
+			Set("keyword3", "keyword3").
+			Set("keyword4", "keyword4").
+			SetWithColor("remark", "remark", "#173177"),
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log("result", "success")
+	t.Log("result", msgId)
 }
 
 func TestBatchGetMaterial(t *testing.T) {
@@ -265,3 +238,49 @@ func TestSendUniformMessage(t *testing.T) {
 	}
 	t.Log("result", "success")
 }
+
+type testComponentTokenSource struct{}
+
+func (s *testComponentTokenSource) GetAccessToken(appId string) (string, int64, error) {
+	return "authorizer-token", 7200, nil
+}
+
+func (s *testComponentTokenSource) ResetAccessToken(appId string) error {
+	return nil
+}
+
+func (s *testComponentTokenSource) ComponentAppId() string {
+	return "wx304925fbea25bcbe"
+}
+
+func (s *testComponentTokenSource) ComponentAccessToken() (string, error) {
+	return "component-token", nil
+}
+
+func TestGetOAuthCodeComponent(t *testing.T) {
+	wx := New(&Config{
+		Name:        "component",
+		AppId:       "wx6c8124f1fbafb1f3",
+		TokenSource: &testComponentTokenSource{},
+		Logger:      logger.NewZap("weixin", "info"),
+		Redis:       account.Redis,
+	})
+	result, err := wx.GetOAuthCode(&OAuthCodeReq{
+		Uri:   "http://192.168.1.101:17000",
+		Scope: ScopeBase,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "component_appid=wx304925fbea25bcbe") {
+		t.Fatal("component_appid missing", result)
+	}
+
+	params, err := wx.snsParams(map[string]string{"code": "code"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["component_access_token"] != "component-token" || params["secret"] != "" {
+		t.Fatal("unexpected params", params)
+	}
+}
@@ -0,0 +1,160 @@
+package weixin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"github.com/go-tron/redis"
+	"strings"
+	"time"
+)
+
+const (
+	WxaCodePrefix = "wx-wxacode:"
+	WxaCodeExpire = time.Hour * 24 * 30
+)
+
+type BlobStore interface {
+	GetBlob(key string) ([]byte, error)
+	SetBlob(key string, data []byte) error
+}
+
+func NewRedisBlobStore(redis *redis.Redis, prefix string, expire time.Duration) BlobStore {
+	return &RedisBlobStore{
+		Redis:  redis,
+		Prefix: prefix,
+		Expire: expire,
+	}
+}
+
+type RedisBlobStore struct {
+	Redis  *redis.Redis
+	Prefix string
+	Expire time.Duration
+}
+
+func (s *RedisBlobStore) GetBlob(key string) ([]byte, error) {
+	data, err := s.Redis.Get(context.Background(), s.Prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *RedisBlobStore) SetBlob(key string, data []byte) error {
+	return s.Redis.Set(context.Background(), s.Prefix+key, data, s.Expire).Err()
+}
+
+type EnvVersion string
+
+const (
+	EnvVersionRelease EnvVersion = "release"
+	EnvVersionTrial   EnvVersion = "trial"
+	EnvVersionDevelop EnvVersion = "develop"
+)
+
+type LineColor struct {
+	R int `json:"r"`
+	G int `json:"g"`
+	B int `json:"b"`
+}
+
+type WxaCodeReq struct {
+	Path       string     `json:"path"`
+	Width      int        `json:"width,omitempty"`
+	AutoColor  bool       `json:"auto_color"`
+	LineColor  *LineColor `json:"line_color,omitempty"`
+	IsHyaline  bool       `json:"is_hyaline"`
+	EnvVersion EnvVersion `json:"env_version,omitempty"`
+}
+
+type WxaCodeUnlimitReq struct {
+	Scene      string     `json:"scene"`
+	Page       string     `json:"page,omitempty"`
+	CheckPath  *bool      `json:"check_path,omitempty"`
+	Width      int        `json:"width,omitempty"`
+	AutoColor  bool       `json:"auto_color"`
+	LineColor  *LineColor `json:"line_color,omitempty"`
+	IsHyaline  bool       `json:"is_hyaline"`
+	EnvVersion EnvVersion `json:"env_version,omitempty"`
+}
+
+type WxaQrCodeReq struct {
+	Path  string `json:"path"`
+	Width int    `json:"width,omitempty"`
+}
+
+func wxaCodeCacheKey(name string, body []byte) string {
+	hash := sha1.New()
+	hash.Write([]byte(name))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (wx *Weixin) fetchWxaCode(name string, url string, params interface{}) ([]byte, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	key := wx.AppId + ":" + wxaCodeCacheKey(name, body)
+	if wx.WxaCodeCache != nil {
+		image, err := wx.WxaCodeCache.GetBlob(key)
+		if err != nil {
+			wx.Logger.Error(name, wx.Logger.Field("error", err), wx.Logger.Field("appId", wx.AppId))
+		}
+		if len(image) > 0 {
+			wx.Logger.Debug(name+" from cache", wx.Logger.Field("appId", wx.AppId))
+			return image, nil
+		}
+	}
+
+	accessToken, err := wx.GetAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := resty.New().R().
+		SetQueryParams(map[string]string{
+			"access_token": accessToken.AccessToken,
+		}).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Post(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(resp.Header().Get("Content-Type"), "image/") || bytes.HasPrefix(resp.Body(), []byte("{")) {
+		if err := decodeRes(name, resp.Body(), nil); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(name)
+	}
+
+	image := resp.Body()
+	if wx.WxaCodeCache != nil {
+		if err := wx.WxaCodeCache.SetBlob(key, image); err != nil {
+			wx.Logger.Error(name, wx.Logger.Field("error", err), wx.Logger.Field("appId", wx.AppId))
+		}
+	}
+	return image, nil
+}
+
+func (wx *Weixin) GetWxaCode(params *WxaCodeReq) ([]byte, error) {
+	return wx.fetchWxaCode("GetWxaCode", "https://api.weixin.qq.com/wxa/getwxacode", params)
+}
+
+func (wx *Weixin) GetWxaCodeUnlimit(params *WxaCodeUnlimitReq) ([]byte, error) {
+	if len(params.Scene) > 32 {
+		return nil, errors.New("scene 最大32个可见字符")
+	}
+	return wx.fetchWxaCode("GetWxaCodeUnlimit", "https://api.weixin.qq.com/wxa/getwxacodeunlimit", params)
+}
+
+func (wx *Weixin) CreateWxaQrCode(params *WxaQrCodeReq) ([]byte, error) {
+	return wx.fetchWxaCode("CreateWxaQrCode", "https://api.weixin.qq.com/cgi-bin/wxaapp/createwxaqrcode", params)
+}